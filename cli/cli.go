@@ -7,13 +7,18 @@ import (
 	"os"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/ecs"
-	"github.com/gawkermedia/ecs/sess"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/vars"
 )
 
 type (
 	// Func The operation
-	Func func(*ecs.ECS, []string) ([]*string, error)
+	Func func(*Clients, []string) (*Result, error)
 	// HelpFunc The desciption of the CLI operation
 	HelpFunc func([]string) *flag.FlagSet
 )
@@ -23,6 +28,54 @@ type Command struct {
 	Cmd  Func
 	Desc string
 	Help HelpFunc
+	// Table The default columns of the table output
+	Table *Table
+}
+
+// Clients The AWS service clients used by the commands
+type Clients struct {
+	ECS ecsiface.ECSAPI
+	EC2 ec2iface.EC2API
+	// Region The AWS region of the clients
+	Region string
+	// Vars The variables substituted in the task definition files
+	Vars vars.Vars
+}
+
+// NewClients Returns the clients of an AWS session
+func NewClients(s *session.Session) *Clients {
+	return &Clients{
+		ECS:    ecs.New(s),
+		EC2:    ec2.New(s),
+		Region: aws.StringValue(s.Config.Region),
+	}
+}
+
+// Result The outcome of a command
+type Result struct {
+	// Value The AWS response objects returned by the command
+	Value interface{}
+	// Lines The default human readable rendering of Value, one item per line
+	Lines []string
+}
+
+// NewResult Returns a result with the given value and its human readable rendering
+func NewResult(value interface{}, lines ...string) *Result {
+	return &Result{Value: value, Lines: lines}
+}
+
+// Strings A command line parameter which can be repeated
+type Strings []string
+
+// String Returns the values separated by commas
+func (s *Strings) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set Appends a value
+func (s *Strings) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
 
 // Get Returns a new command line parser
@@ -64,9 +117,11 @@ func PrintHelp(cmd string, commands map[string]Command, args []string) {
 	}
 }
 
-// Run Main entry point, which runs a command or display a help message.
-func Run(command string, commands map[string]Command, args []string) ([]*string, error) {
-	svc := ecs.New(sess.InitSession())
+// Run Main entry point, which runs a command and prints its result or display a help message.
+func Run(c *Clients, o *Options, command string, commands map[string]Command, args []string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
 
 	var input string
 	if len(args) > 0 {
@@ -77,11 +132,16 @@ func Run(command string, commands map[string]Command, args []string) ([]*string,
 		if len(args) > 1 && args[1] == "help" {
 			fmt.Fprintf(os.Stderr, "\n  # "+input+"\n  "+cmd.Desc+"\n\n  Parameters:\n")
 			cmd.Help(args).PrintDefaults()
-			return nil, nil
+			return nil
 		}
-		ret, err := cmd.Cmd(svc, args[1:]) //func(*ecs.ECS, []string) ([]*string, error))(svc, args[1:])
-		return ret, err
+		ret, err := cmd.Cmd(c, o.Defaults.Apply(cmd.Help(args), args[1:]))
+		if ret != nil {
+			if rerr := o.Render(os.Stdout, ret, cmd.Table); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+		return err
 	}
 	PrintHelp(command, commands, args)
-	return nil, nil
+	return nil
 }
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+)
+
+// Defaults The default values of the command parameters, which are overridden by the command line
+type Defaults struct {
+	// Flags The values by parameter name
+	Flags map[string]interface{}
+	// Families The values by parameter name for the commands run with the given family parameter
+	Families map[string]map[string]interface{}
+}
+
+// Apply Returns the arguments prefixed with the default values of the parameters the command accepts but args does not set.
+// A list value sets a repeatable parameter several times.
+func (d *Defaults) Apply(c *flag.FlagSet, args []string) []string {
+	if d == nil {
+		return args
+	}
+	c.Init("", flag.ContinueOnError)
+	c.SetOutput(ioutil.Discard)
+	if err := c.Parse(args); err != nil {
+		// The command reports the error
+		return args
+	}
+	explicit := map[string]bool{}
+	c.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	values := map[string]interface{}{}
+	for k, v := range d.Flags {
+		values[k] = v
+	}
+	family := ""
+	if f := c.Lookup("family"); f != nil {
+		family = f.Value.String()
+		if v, ok := d.Flags["family"]; ok && !explicit["family"] {
+			family = formatValue(v)
+		}
+	}
+	for k, v := range d.Families[family] {
+		values[k] = v
+	}
+
+	var names = make([]string, 0, len(values))
+	for k := range values {
+		if !explicit[k] && c.Lookup(k) != nil {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	var ret []string
+	for _, k := range names {
+		list, ok := values[k].([]interface{})
+		if !ok {
+			list = []interface{}{values[k]}
+		}
+		for _, v := range list {
+			ret = append(ret, "-"+k+"="+formatValue(v))
+		}
+	}
+	return append(ret, args...)
+}
+
+// formatValue Formats a configuration value as a command line value
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func registerParams() *flag.FlagSet {
+	var c = Get("", nil)
+	c.String("cluster", "default", "")
+	c.String("family", "", "")
+	c.String("image", "", "")
+	c.Int64("cpu", 512, "")
+	c.Bool("essential", true, "")
+	c.Var(&Strings{}, "env", "")
+	return c
+}
+
+func TestDefaultsApply(t *testing.T) {
+	d := &Defaults{
+		Flags: map[string]interface{}{
+			"cluster":   "staging",
+			"unknown":   "ignored",
+			"essential": false,
+		},
+		Families: map[string]map[string]interface{}{
+			"web": {
+				"image": "example/web",
+				"cpu":   float64(256),
+				"env":   []interface{}{"A=1", "B=2"},
+			},
+		},
+	}
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{},
+			want: []string{"-cluster=staging", "-essential=false"},
+		},
+		{
+			args: []string{"-family", "web"},
+			want: []string{"-cluster=staging", "-cpu=256", "-env=A=1", "-env=B=2", "-essential=false", "-image=example/web", "-family", "web"},
+		},
+		{
+			args: []string{"-family", "web", "-cluster", "production", "-env", "C=3"},
+			want: []string{"-cpu=256", "-essential=false", "-image=example/web", "-family", "web", "-cluster", "production", "-env", "C=3"},
+		},
+		{
+			args: []string{"-unknown-flag"},
+			want: []string{"-unknown-flag"},
+		},
+	}
+	for _, test := range tests {
+		if got := d.Apply(registerParams(), test.args); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Apply(%v) = %v, want %v", test.args, got, test.want)
+		}
+	}
+
+	var nilDefaults *Defaults
+	if got := nilDefaults.Apply(registerParams(), []string{"-cpu", "1"}); !reflect.DeepEqual(got, []string{"-cpu", "1"}) {
+		t.Errorf("nil Apply = %v", got)
+	}
+}
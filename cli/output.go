@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/jmespath/go-jmespath"
+)
+
+// The supported output formats
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputTable = "table"
+)
+
+// Options The global options of the command line
+type Options struct {
+	// Output The output format, one of text, json, yaml or table
+	Output string
+	// Query A JMESPath expression selecting the printed part of the result
+	Query string
+	// Template A Go text/template rendering the result, which overrides the output format
+	Template string
+	// Defaults The default values of the command parameters
+	Defaults *Defaults
+}
+
+// Column A column of the table output
+type Column struct {
+	// Header The title of the column
+	Header string
+	// Path The JMESPath expression of the value, evaluated on a row
+	Path string
+}
+
+// Table The layout of a result in the table output
+type Table struct {
+	// Rows The JMESPath expression of the rows, evaluated on the result value
+	Rows string
+	// Columns The columns of a row
+	Columns []Column
+}
+
+// The functions available in the output templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	"join": func(sep string, v []interface{}) string {
+		var items = make([]string, len(v))
+		for i, e := range v {
+			items[i] = cell(e)
+		}
+		return strings.Join(items, sep)
+	},
+}
+
+// Validate Returns an error if the options are invalid
+func (o *Options) Validate() error {
+	switch o.Output {
+	case "", OutputText, OutputJSON, OutputYAML, OutputTable:
+	default:
+		return errors.New("Unknown output format: " + o.Output + ". Possible values: text, json, yaml, table")
+	}
+	if o.Query != "" {
+		if _, err := jmespath.Compile(o.Query); err != nil {
+			return errors.New("Invalid query: " + err.Error())
+		}
+	}
+	if o.Template != "" {
+		if _, err := template.New("output").Funcs(templateFuncs).Parse(o.Template); err != nil {
+			return errors.New("Invalid template: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// Render Writes the result to w in the output format of the options.
+// The table layout is used by the table output only. If it is nil, the lines of the result are printed.
+// The query and the template are applied to the plain form of the result value, which has the field names of the AWS response objects.
+func (o *Options) Render(w io.Writer, r *Result, table *Table) error {
+	if o.Query != "" || o.Template != "" {
+		return o.renderQuery(w, r.Value)
+	}
+	switch o.Output {
+	case OutputJSON:
+		data, err := Plain(r.Value)
+		if err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case OutputYAML:
+		data, err := Plain(r.Value)
+		if err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case OutputTable:
+		if table != nil {
+			return renderTable(w, r.Value, table)
+		}
+	}
+	for _, v := range r.Lines {
+		if _, err := fmt.Fprintln(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderQuery Renders the part of the value selected by the query with the template or in the output format
+func (o *Options) renderQuery(w io.Writer, value interface{}) error {
+	data, err := Plain(value)
+	if err != nil {
+		return err
+	}
+	if o.Query != "" {
+		if data, err = jmespath.Search(o.Query, data); err != nil {
+			return err
+		}
+	}
+	if o.Template != "" {
+		t, err := template.New("output").Funcs(templateFuncs).Parse(o.Template)
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, data)
+	}
+	switch o.Output {
+	case OutputJSON:
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case OutputYAML:
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case OutputTable:
+		return renderRows(w, data)
+	}
+	list, ok := data.([]interface{})
+	if !ok {
+		list = []interface{}{data}
+	}
+	for _, v := range list {
+		if _, err := fmt.Fprintln(w, strings.Join(fields(v), "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderRows Prints a table of the data. The columns are the keys of the maps, or the items of the lists.
+func renderRows(w io.Writer, data interface{}) error {
+	list, ok := data.([]interface{})
+	if !ok {
+		list = []interface{}{data}
+	}
+	var table = &Table{}
+	if len(list) > 0 {
+		if m, ok := list[0].(map[string]interface{}); ok {
+			var keys = make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				table.Columns = append(table.Columns, Column{Header: strings.ToUpper(k), Path: `"` + k + `"`})
+			}
+		}
+	}
+	if len(table.Columns) == 0 {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, v := range list {
+			fmt.Fprintln(tw, strings.Join(fields(v), "\t"))
+		}
+		return tw.Flush()
+	}
+	return renderTable(w, list, table)
+}
+
+// Plain Converts the AWS response objects to maps, slices and scalars, leaving out the unset fields
+func Plain(value interface{}) (interface{}, error) {
+	out, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(out, &data); err != nil {
+		return nil, err
+	}
+	return prune(data), nil
+}
+
+// WriteFile Writes a value to a YAML file, or to a JSON file if the name ends with .json, leaving out the unset fields
+func WriteFile(path string, value interface{}) error {
+	data, err := Plain(value)
+	if err != nil {
+		return err
+	}
+	var out []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		out, err = json.MarshalIndent(data, "", "  ")
+	} else {
+		out, err = yaml.Marshal(data)
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
+
+// prune Removes the null values from the maps
+func prune(data interface{}) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if e == nil {
+				delete(v, k)
+			} else {
+				v[k] = prune(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = prune(e)
+		}
+	}
+	return data
+}
+
+func renderTable(w io.Writer, value interface{}, table *Table) error {
+	data, err := Plain(value)
+	if err != nil {
+		return err
+	}
+	rows, err := search(table.Rows, data)
+	if err != nil {
+		return err
+	}
+	var list []interface{}
+	switch v := rows.(type) {
+	case nil:
+	case []interface{}:
+		list = v
+	default:
+		list = []interface{}{v}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	var headers = make([]string, len(table.Columns))
+	for i, c := range table.Columns {
+		headers[i] = c.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range list {
+		var cells = make([]string, len(table.Columns))
+		for i, c := range table.Columns {
+			v, err := search(c.Path, row)
+			if err != nil {
+				return err
+			}
+			cells[i] = cell(v)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// search Evaluates a JMESPath expression. An empty expression returns the data itself.
+func search(expression string, data interface{}) (interface{}, error) {
+	if expression == "" {
+		return data, nil
+	}
+	return jmespath.Search(expression, data)
+}
+
+// fields Formats the items of a list, or the value itself if it is not a list
+func fields(v interface{}) []string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return []string{cell(v)}
+	}
+	var ret = make([]string, len(list))
+	for i, e := range list {
+		ret[i] = cell(e)
+	}
+	return ret
+}
+
+// cell Formats a value for the table output
+func cell(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case []interface{}:
+		var items = make([]string, len(t))
+		for i, e := range t {
+			items[i] = cell(e)
+		}
+		return strings.Join(items, ",")
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
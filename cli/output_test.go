@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// tasksResult Returns the result of a task listing with its text lines
+func tasksResult() *Result {
+	value := &ecs.DescribeTasksOutput{Tasks: []*ecs.Task{
+		{TaskArn: aws.String("task/1"), LastStatus: aws.String("RUNNING"), Containers: []*ecs.Container{
+			{Name: aws.String("web"), NetworkBindings: []*ecs.NetworkBinding{{HostPort: aws.Int64(80)}, {HostPort: aws.Int64(443)}}},
+		}},
+		{TaskArn: aws.String("task/2"), LastStatus: aws.String("PENDING")},
+	}}
+	return NewResult(value, "task/1 RUNNING", "task/2 PENDING")
+}
+
+var tasksTable = &Table{
+	Rows: "Tasks",
+	Columns: []Column{
+		{Header: "TASK", Path: "TaskArn"},
+		{Header: "STATUS", Path: "LastStatus"},
+		{Header: "PORTS", Path: "Containers[].NetworkBindings[].HostPort"},
+	},
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		table   *Table
+		want    string
+	}{
+		{
+			name:    "text",
+			options: Options{Output: OutputText},
+			table:   tasksTable,
+			want:    "task/1 RUNNING\ntask/2 PENDING\n",
+		},
+		{
+			name:    "json",
+			options: Options{Output: OutputJSON},
+			want: `{
+  "Tasks": [
+    {
+      "Containers": [
+        {
+          "Name": "web",
+          "NetworkBindings": [
+            {
+              "HostPort": 80
+            },
+            {
+              "HostPort": 443
+            }
+          ]
+        }
+      ],
+      "LastStatus": "RUNNING",
+      "TaskArn": "task/1"
+    },
+    {
+      "LastStatus": "PENDING",
+      "TaskArn": "task/2"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:    "yaml",
+			options: Options{Output: OutputYAML},
+			want: `Tasks:
+- Containers:
+  - Name: web
+    NetworkBindings:
+    - HostPort: 80
+    - HostPort: 443
+  LastStatus: RUNNING
+  TaskArn: task/1
+- LastStatus: PENDING
+  TaskArn: task/2
+`,
+		},
+		{
+			name:    "table",
+			options: Options{Output: OutputTable},
+			table:   tasksTable,
+			want: "TASK    STATUS   PORTS\n" +
+				"task/1  RUNNING  80,443\n" +
+				"task/2  PENDING  \n",
+		},
+		{
+			name:    "table without layout",
+			options: Options{Output: OutputTable},
+			want:    "task/1 RUNNING\ntask/2 PENDING\n",
+		},
+		{
+			name:    "query",
+			options: Options{Query: "Tasks[].[TaskArn, LastStatus]"},
+			want:    "task/1\tRUNNING\ntask/2\tPENDING\n",
+		},
+		{
+			name:    "query as json",
+			options: Options{Output: OutputJSON, Query: "Tasks[0].Containers[0].NetworkBindings[].HostPort"},
+			want:    "[\n  80,\n  443\n]\n",
+		},
+		{
+			name:    "query as table",
+			options: Options{Output: OutputTable, Query: "Tasks[].{arn: TaskArn, status: LastStatus}"},
+			table:   tasksTable,
+			want: "ARN     STATUS\n" +
+				"task/1  RUNNING\n" +
+				"task/2  PENDING\n",
+		},
+	}
+	for _, tc := range tests {
+		if err := tc.options.Validate(); err != nil {
+			t.Errorf("%s: Validate error: %s", tc.name, err)
+			continue
+		}
+		var out bytes.Buffer
+		if err := tc.options.Render(&out, tasksResult(), tc.table); err != nil {
+			t.Errorf("%s: Render error: %s", tc.name, err)
+			continue
+		}
+		if out.String() != tc.want {
+			t.Errorf("%s: Render =\n%s\nwant\n%s", tc.name, out.String(), tc.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, tc := range []struct {
+		options Options
+		want    string
+	}{
+		{Options{Output: "xml"}, "Unknown output format: xml"},
+		{Options{Query: "Tasks[?"}, "Invalid query"},
+	} {
+		if err := tc.options.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Validate(%+v) error = %v, want %q", tc.options, err, tc.want)
+		}
+	}
+
+	// A table column which is not a valid expression
+	table := &Table{Rows: "Tasks", Columns: []Column{{Header: "TASK", Path: "TaskArn[?"}}}
+	if err := (&Options{Output: OutputTable}).Render(&bytes.Buffer{}, tasksResult(), table); err == nil {
+		t.Error("Render of a table with an invalid column succeeded")
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		options Options
+		want    string
+	}{
+		{
+			Options{Template: `{{range .Tasks}}{{.TaskArn}} {{.LastStatus}}{{"\n"}}{{end}}`},
+			"task/1 RUNNING\ntask/2 PENDING\n",
+		},
+		{
+			// The template overrides the output format
+			Options{Output: OutputJSON, Template: `{{range .Tasks}}{{json .Containers}};{{end}}`},
+			`[{"Name":"web","NetworkBindings":[{"HostPort":80},{"HostPort":443}]}];null;`,
+		},
+		{
+			// The template renders the result of the query
+			Options{Query: "Tasks[0].Containers[0].NetworkBindings[].HostPort", Template: `ports {{join ", " .}}`},
+			"ports 80, 443",
+		},
+	} {
+		if err := tc.options.Validate(); err != nil {
+			t.Errorf("Validate(%q) error: %s", tc.options.Template, err)
+			continue
+		}
+		var out bytes.Buffer
+		if err := tc.options.Render(&out, tasksResult(), tasksTable); err != nil {
+			t.Errorf("Render(%q) error: %s", tc.options.Template, err)
+			continue
+		}
+		if out.String() != tc.want {
+			t.Errorf("Render(%q) = %q, want %q", tc.options.Template, out.String(), tc.want)
+		}
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	for _, tmpl := range []string{"{{.Tasks", "{{range .Tasks}}", "{{upper .TaskArn}}"} {
+		o := &Options{Template: tmpl}
+		if err := o.Validate(); err == nil || !strings.HasPrefix(err.Error(), "Invalid template") {
+			t.Errorf("Validate(%q) error = %v, want an invalid template", tmpl, err)
+		}
+	}
+	// The template is valid, but join is not given a list
+	o := &Options{Template: `{{join "," .Tasks.Foo}}`}
+	if err := o.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Render(&bytes.Buffer{}, tasksResult(), nil); err == nil {
+		t.Error("Render of a failing template succeeded")
+	}
+}
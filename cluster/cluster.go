@@ -3,18 +3,14 @@ package cluster
 import (
 	"flag"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
 	"github.com/gawkermedia/ecs/cli"
 )
 
-// CLI params
-var cliMaxResults int64
-var cliClusterName string
-
-// CLI params END
-
 // ListClusters List ECS clusters
-func ListClusters(svc *ecs.ECS, maxResults *int64) (*ecs.ListClustersOutput, error) {
+func ListClusters(svc ecsiface.ECSAPI, maxResults *int64) (*ecs.ListClustersOutput, error) {
 	params := &ecs.ListClustersInput{
 		MaxResults: maxResults,
 	}
@@ -25,23 +21,35 @@ func ListClusters(svc *ecs.ECS, maxResults *int64) (*ecs.ListClustersOutput, err
 	return resp, nil
 }
 
-func cliListClustersParams(args []string) *flag.FlagSet {
+// listClustersOptions The parameters of the list command
+type listClustersOptions struct {
+	maxResults int64
+}
+
+func (o *listClustersOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.Int64Var(&cliMaxResults, "max-items", 1, "The maximum number of cluster results returned by ListClusters in paginated output")
+	c.Int64Var(&o.maxResults, "max-items", 1, "The maximum number of cluster results returned by ListClusters in paginated output")
 	return c
 }
 
-func cliListClusters(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliListClustersParams(args).Parse(args)
-	resp, err := ListClusters(svc, &cliMaxResults)
+func cliListClustersParams(args []string) *flag.FlagSet {
+	return new(listClustersOptions).flags(args)
+}
+
+func cliListClusters(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o listClustersOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	resp, err := ListClusters(c.ECS, &o.maxResults)
 	if err != nil {
 		return nil, err
 	}
-	return resp.ClusterArns, nil
+	return cli.NewResult(resp, aws.StringValueSlice(resp.ClusterArns)...), nil
 }
 
 // CreateCluster Creates a new ECS cluster
-func CreateCluster(svc *ecs.ECS, name *string) (*ecs.CreateClusterOutput, error) {
+func CreateCluster(svc ecsiface.ECSAPI, name *string) (*ecs.CreateClusterOutput, error) {
 	params := &ecs.CreateClusterInput{
 		ClusterName: name,
 	}
@@ -52,23 +60,35 @@ func CreateCluster(svc *ecs.ECS, name *string) (*ecs.CreateClusterOutput, error)
 	return resp, nil
 }
 
-func cliClusterNameParams(args []string) *flag.FlagSet {
+// clusterNameOptions The parameters of the commands acting on a single cluster
+type clusterNameOptions struct {
+	name string
+}
+
+func (o *clusterNameOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.StringVar(&cliClusterName, "cluster", "defaut", "The name of your cluster. If you do not specify a name for your cluster, If you do not specify a cluster, the default cluster is assumed. Up to 255 letters (uppercase and lowercase), numbers, hyphens, and underscores are allowed.")
+	c.StringVar(&o.name, "cluster", "default", "The name of your cluster. If you do not specify a name for your cluster, If you do not specify a cluster, the default cluster is assumed. Up to 255 letters (uppercase and lowercase), numbers, hyphens, and underscores are allowed.")
 	return c
 }
 
-func cliCreateCluster(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliClusterNameParams(args).Parse(args)
-	resp, err := CreateCluster(svc, &cliClusterName)
+func cliClusterNameParams(args []string) *flag.FlagSet {
+	return new(clusterNameOptions).flags(args)
+}
+
+func cliCreateCluster(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o clusterNameOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	resp, err := CreateCluster(c.ECS, &o.name)
 	if err != nil {
 		return nil, err
 	}
-	return []*string{resp.Cluster.ClusterName}, nil
+	return cli.NewResult(resp, *resp.Cluster.ClusterName), nil
 }
 
 // DeleteCluster Removes an ECS cluster
-func DeleteCluster(svc *ecs.ECS, name *string) (*ecs.DeleteClusterOutput, error) {
+func DeleteCluster(svc ecsiface.ECSAPI, name *string) (*ecs.DeleteClusterOutput, error) {
 	params := &ecs.DeleteClusterInput{
 		Cluster: name,
 	}
@@ -79,34 +99,56 @@ func DeleteCluster(svc *ecs.ECS, name *string) (*ecs.DeleteClusterOutput, error)
 	return resp, nil
 }
 
-func cliDeleteCluster(svc *ecs.ECS, args []string) ([]*string, error) {
-	cliClusterNameParams(args)
-	resp, err := DeleteCluster(svc, &cliClusterName)
+func cliDeleteCluster(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o clusterNameOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	resp, err := DeleteCluster(c.ECS, &o.name)
 	if err != nil {
 		return nil, err
 	}
-	return []*string{resp.Cluster.ClusterName}, nil
+	return cli.NewResult(resp, *resp.Cluster.ClusterName), nil
+}
+
+// The table layout of a single cluster
+var clusterTable = &cli.Table{
+	Rows: "Cluster",
+	Columns: []cli.Column{
+		{Header: "NAME", Path: "ClusterName"},
+		{Header: "STATUS", Path: "Status"},
+		{Header: "INSTANCES", Path: "RegisteredContainerInstancesCount"},
+		{Header: "RUNNING", Path: "RunningTasksCount"},
+		{Header: "PENDING", Path: "PendingTasksCount"},
+		{Header: "CLUSTER ARN", Path: "ClusterArn"},
+	},
 }
 
 var commands = map[string]cli.Command{
 	"list": {
-		cliListClusters,
-		"Returns a list of existing clusters.",
-		cliListClustersParams,
+		Cmd:  cliListClusters,
+		Desc: "Returns a list of existing clusters.",
+		Help: cliListClustersParams,
+		Table: &cli.Table{
+			Rows:    "ClusterArns",
+			Columns: []cli.Column{{Header: "CLUSTER ARN"}},
+		},
 	},
 	"create": {
-		cliCreateCluster,
-		"Creates a new Amazon ECS cluster.",
-		cliClusterNameParams,
+		Cmd:   cliCreateCluster,
+		Desc:  "Creates a new Amazon ECS cluster.",
+		Help:  cliClusterNameParams,
+		Table: clusterTable,
 	},
 	"delete": {
-		cliDeleteCluster,
-		"Deletes the specified cluster. You must deregister all container instances from this cluster before you may delete it.",
-		cliClusterNameParams,
+		Cmd:   cliDeleteCluster,
+		Desc:  "Deletes the specified cluster. You must deregister all container instances from this cluster before you may delete it.",
+		Help:  cliClusterNameParams,
+		Table: clusterTable,
 	},
 }
 
 // Run Main entry point, which runs a command or display a help message.
-func Run(command string, args []string) ([]*string, error) {
-	return cli.Run(command, commands, args)
+func Run(c *cli.Clients, o *cli.Options, command string, args []string) error {
+	return cli.Run(c, o, command, commands, args)
 }
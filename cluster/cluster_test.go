@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+// fakeECS Keeps the clusters in memory
+type fakeECS struct {
+	ecsiface.ECSAPI
+	clusters []*ecs.Cluster
+}
+
+func (f *fakeECS) CreateCluster(in *ecs.CreateClusterInput) (*ecs.CreateClusterOutput, error) {
+	c := &ecs.Cluster{
+		ClusterArn:  aws.String("arn:aws:ecs:us-east-1:123456789012:cluster/" + *in.ClusterName),
+		ClusterName: in.ClusterName,
+		Status:      aws.String("ACTIVE"),
+	}
+	f.clusters = append(f.clusters, c)
+	return &ecs.CreateClusterOutput{Cluster: c}, nil
+}
+
+func (f *fakeECS) ListClusters(in *ecs.ListClustersInput) (*ecs.ListClustersOutput, error) {
+	out := &ecs.ListClustersOutput{}
+	for _, c := range f.clusters {
+		if in.MaxResults != nil && int64(len(out.ClusterArns)) >= *in.MaxResults {
+			break
+		}
+		out.ClusterArns = append(out.ClusterArns, c.ClusterArn)
+	}
+	return out, nil
+}
+
+func (f *fakeECS) DeleteCluster(in *ecs.DeleteClusterInput) (*ecs.DeleteClusterOutput, error) {
+	for i, c := range f.clusters {
+		if *c.ClusterName == *in.Cluster {
+			f.clusters = append(f.clusters[:i], f.clusters[i+1:]...)
+			c.Status = aws.String("INACTIVE")
+			return &ecs.DeleteClusterOutput{Cluster: c}, nil
+		}
+	}
+	return nil, errors.New("ClusterNotFoundException: Cluster not found.")
+}
+
+func TestClusterLifecycle(t *testing.T) {
+	svc := &fakeECS{}
+
+	created, err := CreateCluster(svc, aws.String("staging"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *created.Cluster.Status != "ACTIVE" {
+		t.Errorf("created cluster status = %s, want ACTIVE", *created.Cluster.Status)
+	}
+
+	list, err := ListClusters(svc, aws.Int64(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*string{created.Cluster.ClusterArn}; !reflect.DeepEqual(list.ClusterArns, want) {
+		t.Errorf("ListClusters = %v, want %v", aws.StringValueSlice(list.ClusterArns), aws.StringValueSlice(want))
+	}
+
+	deleted, err := DeleteCluster(svc, aws.String("staging"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *deleted.Cluster.Status != "INACTIVE" {
+		t.Errorf("deleted cluster status = %s, want INACTIVE", *deleted.Cluster.Status)
+	}
+
+	list, err = ListClusters(svc, aws.Int64(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.ClusterArns) != 0 {
+		t.Errorf("ListClusters after delete = %v, want none", aws.StringValueSlice(list.ClusterArns))
+	}
+
+	if _, err := DeleteCluster(svc, aws.String("staging")); err == nil {
+		t.Error("DeleteCluster of a missing cluster succeeded")
+	}
+}
+
+func TestCliCommands(t *testing.T) {
+	c := &cli.Clients{ECS: &fakeECS{}}
+
+	for _, name := range []string{"web", "worker"} {
+		ret, err := commands["create"].Cmd(c, []string{"-cluster", name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{name}; !reflect.DeepEqual(ret.Lines, want) {
+			t.Errorf("create lines = %v, want %v", ret.Lines, want)
+		}
+	}
+
+	ret, err := commands["list"].Cmd(c, []string{"-max-items", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ret.Lines) != 1 {
+		t.Errorf("list -max-items 1 returned %d clusters", len(ret.Lines))
+	}
+
+	ret, err = commands["delete"].Cmd(c, []string{"-cluster", "worker"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ret.Value.(*ecs.DeleteClusterOutput); !ok {
+		t.Errorf("delete value is %T, want *ecs.DeleteClusterOutput", ret.Value)
+	}
+	if want := []string{"worker"}; !reflect.DeepEqual(ret.Lines, want) {
+		t.Errorf("delete lines = %v, want %v", ret.Lines, want)
+	}
+}
@@ -0,0 +1,167 @@
+// Package config Reads the project configuration file, which defines named environments
+// with their AWS settings and the default values of the command parameters.
+//
+//	environments:
+//	  staging:
+//	    region: eu-west-1
+//	    profile: staging
+//	    cluster: staging
+//	    flags:
+//	      max-tries: 20
+//	    families:
+//	      web:
+//	        image: example/web:latest
+//	        cpu: 256
+//	        memory: 512
+//	  production:
+//	    region: us-east-1
+//	    cluster: ${CLUSTER:-production}
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gawkermedia/ecs/vars"
+	"github.com/ghodss/yaml"
+)
+
+// DefaultFile The configuration file looked up in the working directory
+const DefaultFile = ".ecs.yaml"
+
+// File The project configuration
+type File struct {
+	// Environments The environments by name
+	Environments map[string]*Environment
+
+	path string
+	vars vars.Vars
+}
+
+// Environment The settings of a deployment environment
+type Environment struct {
+	Region     string
+	Profile    string
+	RoleArn    string
+	ExternalID string
+	MFASerial  string
+	// Cluster The default value of the cluster parameter
+	Cluster string
+	// Flags The default values of the command parameters by parameter name
+	Flags map[string]interface{}
+	// Families The default values of the command parameters by parameter name, used when the family parameter is the key
+	Families map[string]map[string]interface{}
+}
+
+// Load Reads a JSON or YAML configuration file. A template file is rendered before parsing,
+// but the variables are only substituted in the environment returned by File.Environment.
+func Load(path string, v vars.Vars) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = v.Render(path, data)
+	if err != nil {
+		return nil, err
+	}
+	var f = File{path: path, vars: v}
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, errors.New("Invalid configuration file " + path + ": " + err.Error())
+	}
+	return &f, nil
+}
+
+// Find Reads the configuration file at path. If path is empty, DefaultFile is read from the working directory if it exists.
+// It returns nil if there is no configuration file.
+func Find(path string, v vars.Vars) (*File, error) {
+	if path != "" {
+		return Load(path, v)
+	}
+	f, err := Load(DefaultFile, v)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// Environment Returns the environment by name, with the variables substituted in its values.
+// The error lists every variable of the environment without a value.
+func (f *File) Environment(name string) (*Environment, error) {
+	if env, ok := f.Environments[name]; ok && env != nil {
+		return f.substitute(name, env)
+	}
+	var names = make([]string, 0, len(f.Environments))
+	for k := range f.Environments {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return nil, errors.New("Unknown environment: " + name + ". Available environments: " + strings.Join(names, ", "))
+}
+
+// Parameters Returns the default values of the command parameters, including the cluster
+func (e *Environment) Parameters() map[string]interface{} {
+	var ret = make(map[string]interface{}, len(e.Flags)+1)
+	if e.Cluster != "" {
+		ret["cluster"] = e.Cluster
+	}
+	for k, v := range e.Flags {
+		ret[k] = v
+	}
+	return ret
+}
+
+// substitute Returns a copy of an environment with the variables substituted in its values
+func (f *File) substitute(name string, env *Environment) (*Environment, error) {
+	missing := map[string]bool{}
+	ret := &Environment{
+		Region:     f.vars.Substitute(env.Region, missing),
+		Profile:    f.vars.Substitute(env.Profile, missing),
+		RoleArn:    f.vars.Substitute(env.RoleArn, missing),
+		ExternalID: f.vars.Substitute(env.ExternalID, missing),
+		MFASerial:  f.vars.Substitute(env.MFASerial, missing),
+		Cluster:    f.vars.Substitute(env.Cluster, missing),
+		Flags:      f.substituteMap(env.Flags, missing),
+	}
+	if env.Families != nil {
+		ret.Families = make(map[string]map[string]interface{}, len(env.Families))
+		for family, flags := range env.Families {
+			ret.Families[family] = f.substituteMap(flags, missing)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, vars.Unresolved("the "+name+" environment of "+f.path, missing)
+	}
+	return ret, nil
+}
+
+// substituteMap Returns a copy of the parameter values with the variables substituted in the strings
+func (f *File) substituteMap(m map[string]interface{}, missing map[string]bool) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	ret := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		ret[k] = f.substituteValue(v, missing)
+	}
+	return ret
+}
+
+// substituteValue Returns a copy of a parsed value with the variables substituted in the strings
+func (f *File) substituteValue(v interface{}, missing map[string]bool) interface{} {
+	switch v := v.(type) {
+	case string:
+		return f.vars.Substitute(v, missing)
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = f.substituteValue(item, missing)
+		}
+		return ret
+	case map[string]interface{}:
+		return f.substituteMap(v, missing)
+	}
+	return v
+}
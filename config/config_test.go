@@ -0,0 +1,125 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gawkermedia/ecs/vars"
+)
+
+const testConfig = `
+environments:
+  staging:
+    region: eu-west-1
+    profile: staging
+    cluster: staging-cluster
+    flags:
+      max-tries: 20
+    families:
+      web:
+        image: example/web:${TAG:-latest}
+        cpu: 256
+  production:
+    region: ${REGION:-us-west-2}
+    cluster: ${CLUSTER}
+    roleArn: arn:aws:iam::123456789012:role/deploy
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ecs.yaml")
+	if err := ioutil.WriteFile(path, []byte(testConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Find(path, vars.Vars{"CLUSTER": "production"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging, err := f.Environment("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.Region != "eu-west-1" || staging.Profile != "staging" {
+		t.Errorf("staging = %+v", staging)
+	}
+	want := map[string]interface{}{"cluster": "staging-cluster", "max-tries": float64(20)}
+	if got := staging.Parameters(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parameters = %v, want %v", got, want)
+	}
+	if got := staging.Families["web"]["image"]; got != "example/web:latest" {
+		t.Errorf("web image = %v", got)
+	}
+
+	production, err := f.Environment("production")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if production.Region != "us-west-2" || production.Cluster != "production" {
+		t.Errorf("production = %+v", production)
+	}
+	if production.RoleArn != "arn:aws:iam::123456789012:role/deploy" {
+		t.Errorf("production role = %s", production.RoleArn)
+	}
+
+	if _, err := f.Environment("qa"); err == nil || err.Error() != "Unknown environment: qa. Available environments: production, staging" {
+		t.Errorf("Environment(qa) error = %v", err)
+	}
+}
+
+func TestLoadUnresolvedEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ecs.yaml")
+	if err := ioutil.WriteFile(path, []byte(testConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The variables of the environments which are not selected are not resolved
+	f, err := Find(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging, err := f.Environment("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.Cluster != "staging-cluster" || staging.Families["web"]["image"] != "example/web:latest" {
+		t.Errorf("staging = %+v", staging)
+	}
+	want := "Unresolved variables in the production environment of " + path + ": CLUSTER"
+	if _, err := f.Environment("production"); err == nil || err.Error() != want {
+		t.Errorf("Environment(production) error = %v, want %q", err, want)
+	}
+	if f.Environments["production"].Cluster != "${CLUSTER}" {
+		t.Errorf("Environment changed the parsed environment: %+v", f.Environments["production"])
+	}
+}
+
+func TestFindMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	if f, err := Find("", nil); f != nil || err != nil {
+		t.Errorf("Find without a configuration file = %v, %v", f, err)
+	}
+	if _, err := Find("missing.yaml", nil); err == nil {
+		t.Error("Find of an explicit missing file succeeded")
+	}
+}
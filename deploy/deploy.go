@@ -0,0 +1,175 @@
+package deploy
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+	"github.com/gawkermedia/ecs/service"
+	"github.com/gawkermedia/ecs/task"
+)
+
+// Changes The changes of the task definition of a service to deploy
+type Changes struct {
+	// Image The new image of the container
+	Image string
+	// Container The name of the changed container. It can be empty if the task definition has a single container.
+	Container string
+	// Env The environment variables of the container to set, in the KEY=VALUE form
+	Env []string
+	// UnsetEnv The names of the environment variables of the container to remove
+	UnsetEnv []string
+}
+
+// Output The result of a deployment of an image
+type Output struct {
+	// TaskDefinitionArn The ARN of the registered revision of the task definition
+	TaskDefinitionArn string
+	Deployment        *service.DeployOutput
+}
+
+// container Returns the container of a task definition changed by a deployment
+func container(params *ecs.RegisterTaskDefinitionInput, name string) (*ecs.ContainerDefinition, error) {
+	var names []string
+	for _, c := range params.ContainerDefinitions {
+		if name == "" && len(params.ContainerDefinitions) == 1 || aws.StringValue(c.Name) == name {
+			return c, nil
+		}
+		names = append(names, aws.StringValue(c.Name))
+	}
+	if name == "" {
+		return nil, errors.New("The task definition has several containers: " + strings.Join(names, ", ") + ". Set the container parameter")
+	}
+	return nil, errors.New("There is no " + name + " container in the task definition. Its containers are: " + strings.Join(names, ", "))
+}
+
+// Apply Returns the input registering a copy of a task definition with the changes applied
+func Apply(td *ecs.TaskDefinition, ch *Changes) (*ecs.RegisterTaskDefinitionInput, error) {
+	params, err := task.RegisterInput(td)
+	if err != nil {
+		return nil, err
+	}
+	c, err := container(params, ch.Container)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Image != "" {
+		c.Image = aws.String(ch.Image)
+	}
+	if len(ch.Env) == 0 && len(ch.UnsetEnv) == 0 {
+		return params, nil
+	}
+	unset := map[string]bool{}
+	for _, name := range ch.UnsetEnv {
+		unset[name] = true
+	}
+	var env []string
+	for _, kv := range c.Environment {
+		if !unset[aws.StringValue(kv.Name)] {
+			env = append(env, aws.StringValue(kv.Name)+"="+aws.StringValue(kv.Value))
+		}
+	}
+	if c.Environment, err = task.Environment(append(env, ch.Env...)); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// Image Registers a new revision of the task definition of a service with the changes applied,
+// then deploys it to the service the way service.Deploy does
+func Image(svc ecsiface.ECSAPI, cluster *string, serviceName *string, ch *Changes, w *service.Wait, rollback bool) (*Output, error) {
+	desc, err := service.DescribeServices(svc, cluster, []*string{serviceName})
+	if err != nil {
+		return nil, err
+	}
+	td, err := task.DescribeTaskDef(svc, desc.Services[0].TaskDefinition)
+	if err != nil {
+		return nil, err
+	}
+	params, err := Apply(td.TaskDefinition, ch)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := task.RegisterTask(svc, params)
+	if err != nil {
+		return nil, err
+	}
+	out := &Output{TaskDefinitionArn: aws.StringValue(resp.TaskDefinition.TaskDefinitionArn)}
+	out.Deployment, err = service.Deploy(svc, cluster, serviceName, resp.TaskDefinition.TaskDefinitionArn, w, rollback)
+	return out, err
+}
+
+// deployOptions The parameters of the deploy command
+type deployOptions struct {
+	service.WaitOptions
+	cluster   string
+	service   string
+	image     string
+	container string
+	env       cli.Strings
+	unsetEnv  cli.Strings
+}
+
+func (o *deployOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	c.StringVar(&o.cluster, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the service. If you do not specify a cluster, the default cluster is assumed.")
+	c.StringVar(&o.service, "service", "", "The name or full Amazon Resource Name (ARN) of the service.")
+	c.StringVar(&o.image, "image", "", "The image of the container in the new revision of the task definition, e.g. example/web:1.2.")
+	c.StringVar(&o.container, "container", "", "The name of the container to change. It can be omitted if the task definition has a single container.")
+	c.Var(&o.env, "env", "An environment variable of the container to set in the form 'KEY=VALUE'. Can be repeated.")
+	c.Var(&o.unsetEnv, "unset-env", "The name of an environment variable of the container to remove. Can be repeated.")
+	o.WaitOptions.Flags(c)
+	return c
+}
+
+func cliDeployParams(args []string) *flag.FlagSet {
+	return new(deployOptions).flags(args)
+}
+
+func cliDeploy(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o deployOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if o.service == "" || o.image == "" && len(o.env) == 0 && len(o.unsetEnv) == 0 {
+		return nil, errors.New("The service parameter and the image or env parameters are required")
+	}
+	ch := &Changes{Image: o.image, Container: o.container, Env: o.env, UnsetEnv: o.unsetEnv}
+	out, err := Image(c.ECS, &o.cluster, &o.service, ch, o.Wait(), o.Rollback)
+	if out == nil {
+		return nil, err
+	}
+	return cli.NewResult(out, out.TaskDefinitionArn), err
+}
+
+var commands = map[string]cli.Command{
+	"deploy": {
+		Cmd:  cliDeploy,
+		Desc: "Registers a new revision of the task definition of a service with the image or the environment of a container changed, then deploys it like service deploy and prints the ARN of the new revision.",
+		Help: cliDeployParams,
+		Table: &cli.Table{
+			Rows: "@",
+			Columns: []cli.Column{
+				{Header: "TASK DEFINITION", Path: "TaskDefinitionArn"},
+				{Header: "PREVIOUS", Path: "Deployment.Previous"},
+				{Header: "ROLLED BACK", Path: "Deployment.RolledBack"},
+			},
+		},
+	},
+}
+
+// Run Main entry point, which runs the deploy command or display its help message.
+// The command has no subcommand, so its name is the one of the command of the registry.
+func Run(c *cli.Clients, o *cli.Options, command string, args []string) error {
+	if len(args) == 1 && args[0] == command {
+		// ecs help deploy
+		args = []string{command, "help"}
+	} else {
+		args = append([]string{command}, args...)
+	}
+	return cli.Run(c, o, command, commands, args)
+}
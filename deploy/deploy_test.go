@@ -0,0 +1,99 @@
+package deploy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/fake"
+	"github.com/gawkermedia/ecs/service"
+)
+
+func TestApply(t *testing.T) {
+	td := &ecs.TaskDefinition{
+		Family:            aws.String("web"),
+		Revision:          aws.Int64(4),
+		TaskDefinitionArn: aws.String("arn:aws:ecs:us-east-1:" + fake.Account + ":task-definition/web:4"),
+		ContainerDefinitions: []*ecs.ContainerDefinition{
+			{Name: aws.String("app"), Image: aws.String("example/web:1"), Environment: []*ecs.KeyValuePair{
+				{Name: aws.String("DEBUG"), Value: aws.String("1")},
+				{Name: aws.String("PORT"), Value: aws.String("9000")},
+			}},
+			{Name: aws.String("proxy"), Image: aws.String("nginx")},
+		},
+	}
+	if _, err := Apply(td, &Changes{Image: "example/web:2"}); err == nil {
+		t.Error("Apply without the container of a task definition with several containers succeeded")
+	}
+	if _, err := Apply(td, &Changes{Image: "example/web:2", Container: "worker"}); err == nil {
+		t.Error("Apply to a missing container succeeded")
+	}
+
+	params, err := Apply(td, &Changes{Image: "example/web:2", Container: "app", Env: []string{"PORT=8080", "LOG=json"}, UnsetEnv: []string{"DEBUG"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := params.ContainerDefinitions[0]
+	if *c.Image != "example/web:2" || *params.ContainerDefinitions[1].Image != "nginx" || *params.Family != "web" {
+		t.Errorf("changed task definition = %v", params)
+	}
+	want := []*ecs.KeyValuePair{
+		{Name: aws.String("LOG"), Value: aws.String("json")},
+		{Name: aws.String("PORT"), Value: aws.String("8080")},
+	}
+	if !reflect.DeepEqual(c.Environment, want) {
+		t.Errorf("environment = %v, want %v", c.Environment, want)
+	}
+	if len(td.ContainerDefinitions[0].Environment) != 2 || *td.ContainerDefinitions[0].Image != "example/web:1" {
+		t.Errorf("Apply changed the task definition: %v", td)
+	}
+}
+
+func TestCliDeploy(t *testing.T) {
+	state := fake.DefaultState()
+	state.Failing = map[string]string{"example/web:crash": "Essential container in task exited"}
+	b := fake.New(state, "us-east-1")
+	c := b.Clients()
+	_, err := b.RegisterTaskDefinition(&ecs.RegisterTaskDefinitionInput{
+		Family:               aws.String("web"),
+		ContainerDefinitions: []*ecs.ContainerDefinition{{Name: aws.String("app"), Image: aws.String("example/web:1"), Memory: aws.Int64(128)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.CreateService(b, &ecs.CreateServiceInput{ServiceName: aws.String("web"), TaskDefinition: aws.String("web:1"), DesiredCount: aws.Int64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := commands["deploy"].Cmd(c, []string{"-service", "web", "-image", "example/web:2", "-env", "LOG=json", "-interval", "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := ret.Value.(*Output)
+	if !strings.HasSuffix(out.TaskDefinitionArn, "task-definition/web:2") || !reflect.DeepEqual(ret.Lines, []string{out.TaskDefinitionArn}) {
+		t.Errorf("deploy lines = %q", ret.Lines)
+	}
+	if s := out.Deployment.Service; !service.Stable(s) || *s.TaskDefinition != out.TaskDefinitionArn {
+		t.Errorf("deployed service = %v", s)
+	}
+
+	ret, err = commands["deploy"].Cmd(c, []string{"-service", "web", "-image", "example/web:crash", "-interval", "0"})
+	if err == nil || !strings.Contains(err.Error(), "Rolled back to") {
+		t.Fatalf("deploy of a crashing image error = %v", err)
+	}
+	out = ret.Value.(*Output)
+	if !strings.HasSuffix(out.TaskDefinitionArn, "web:3") || !out.Deployment.RolledBack || !strings.HasSuffix(*out.Deployment.Service.TaskDefinition, "web:2") {
+		t.Errorf("deploy output after the rollback = %+v", out)
+	}
+
+	td, err := b.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: aws.String("web:3")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env := td.TaskDefinition.ContainerDefinitions[0].Environment; len(env) != 1 || *env[0].Name != "LOG" {
+		t.Errorf("environment of the new revision = %v, want the one of the deployed revision", env)
+	}
+}
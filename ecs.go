@@ -1,52 +1,210 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/gawkermedia/ecs/cli"
 	"github.com/gawkermedia/ecs/cluster"
+	"github.com/gawkermedia/ecs/config"
+	"github.com/gawkermedia/ecs/deploy"
+	"github.com/gawkermedia/ecs/fake"
+	"github.com/gawkermedia/ecs/service"
+	"github.com/gawkermedia/ecs/sess"
 	"github.com/gawkermedia/ecs/task"
+	"github.com/gawkermedia/ecs/vars"
 )
 
-func printHelp() {
-	fmt.Fprintf(os.Stdout, "Usage: "+os.Args[0]+" command [parameters]\n")
+func printHelp(global *flag.FlagSet) {
+	fmt.Fprintf(os.Stdout, "Usage: "+os.Args[0]+" [global parameters] command [parameters]\n")
 	fmt.Fprintf(os.Stdout, "Help: "+os.Args[0]+" help [command]\n")
-	fmt.Fprintf(os.Stdout, "Available commands: cluster task\n")
+	fmt.Fprintf(os.Stdout, "Available commands: cluster deploy service task\n")
+	fmt.Fprintf(os.Stdout, "Global parameters:\n")
+	global.SetOutput(os.Stdout)
+	global.PrintDefaults()
 }
 
-// ecs cmd [args...]
+// globals The parameters shared by every command
+type globals struct {
+	session   sess.Config
+	options   cli.Options
+	backend   string
+	fakeState string
+	endpoints cli.Strings
+	config    string
+	env       string
+	vars      cli.Strings
+	varsFile  string
+	values    vars.Vars
+}
+
+// Returns a `FlagSet` for the parameters shared by every command
+func globalParams(g *globals) *flag.FlagSet {
+	var c = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	c.StringVar(&g.session.Region, "region", os.Getenv("AWS_REGION"), "The AWS region to send the requests to. Defaults to the AWS_REGION environment variable, then to the region of the profile, then to "+sess.DefaultRegion+".")
+	c.StringVar(&g.session.Profile, "profile", os.Getenv("AWS_PROFILE"), "The shared credentials profile to use. Defaults to the AWS_PROFILE environment variable.")
+	c.StringVar(&g.session.Endpoint, "endpoint-url", "", "Override the default endpoint URL of the AWS services.")
+	c.Var(&g.endpoints, "service-endpoint", "Override the endpoint URL of a single AWS service in the form `service=url`, e.g. ecs=http://localhost:4000. The service is the endpoint ID: ecs, ec2, sts... Can be repeated.")
+	c.BoolVar(&g.session.Insecure, "no-verify-ssl", false, "Do not verify the TLS certificates of the endpoints.")
+	c.StringVar(&g.session.RoleArn, "role-arn", "", "The ARN of an IAM role to assume with the credentials of the profile. The temporary credentials are cached in ~/.ecs/cache until they expire.")
+	c.StringVar(&g.session.ExternalID, "external-id", "", "The external ID to pass when assuming the role.")
+	c.StringVar(&g.session.MFASerial, "mfa-serial", "", "The serial number or ARN of the MFA device to use when assuming the role. The token code is read from the terminal.")
+	c.StringVar(&g.options.Output, "output", cli.OutputText, "The output format: text, json, yaml or table.")
+	c.StringVar(&g.options.Query, "query", "", "A JMESPath expression selecting the printed part of the result, e.g. 'Tasks[*].Containers[*].NetworkBindings'. The field names are the ones of the AWS API responses.")
+	c.StringVar(&g.options.Template, "template", "", "A Go text/template rendering the result, e.g. '{{range .Tasks}}{{.TaskArn}} {{.LastStatus}}{{\"\\n\"}}{{end}}'. It overrides the output format. The json and join functions are available.")
+	c.StringVar(&g.config, "config", "", "The project configuration file. Defaults to "+config.DefaultFile+" in the working directory. Its settings are applied only with an environment selected by -env.")
+	c.StringVar(&g.env, "env", os.Getenv("ECS_ENV"), "The environment of the configuration file to use. Its settings are overridden by the command line. Defaults to the ECS_ENV environment variable.")
+	c.Var(&g.vars, "var", "Set a variable substituted in the task definition and configuration files, in the form 'name=value'. Can be repeated. It overrides the vars file and the environment variables.")
+	c.StringVar(&g.varsFile, "vars-file", "", "A JSON or YAML file with the variables substituted in the task definition and configuration files. It overrides the environment variables.")
+	c.StringVar(&g.backend, "backend", "aws", "The backend serving the API calls: aws, or fake for an in-memory ECS and EC2 simulation.")
+	c.StringVar(&g.fakeState, "fake-state", "", "The JSON or YAML file the fake backend is seeded from and saves its state to. A default cluster with two container instances is used if it is missing.")
+	return c
+}
+
+// loadVars Collects the variables of the vars file and the command line
+func loadVars(g *globals) error {
+	g.values = vars.Vars{}
+	if g.varsFile != "" {
+		v, err := vars.Load(g.varsFile)
+		if err != nil {
+			return err
+		}
+		g.values = v
+	}
+	return g.values.Set(g.vars)
+}
+
+// applyConfig Applies the settings of the selected environment of the configuration file,
+// except the ones set on the command line. A configuration file given without environment is an error.
+func applyConfig(g *globals, global *flag.FlagSet) error {
+	if g.env == "" {
+		if g.config != "" {
+			return errors.New("The configuration file " + g.config + " is given without environment. Select one with -env or the ECS_ENV environment variable")
+		}
+		return nil
+	}
+	f, err := config.Find(g.config, g.values)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return errors.New("The " + g.env + " environment is selected, but there is no " + config.DefaultFile + " configuration file")
+	}
+	env, err := f.Environment(g.env)
+	if err != nil {
+		return err
+	}
+	explicit := map[string]bool{}
+	global.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	for name, v := range map[string]*string{
+		"region":      &env.Region,
+		"profile":     &env.Profile,
+		"role-arn":    &env.RoleArn,
+		"external-id": &env.ExternalID,
+		"mfa-serial":  &env.MFASerial,
+	} {
+		if !explicit[name] && *v != "" {
+			global.Set(name, *v)
+		}
+	}
+	g.options.Defaults = &cli.Defaults{
+		Flags:    env.Parameters(),
+		Families: env.Families,
+	}
+	return nil
+}
+
+// runCommand Runs a command with the clients of the selected backend
+func runCommand(g *globals, run func(*cli.Clients, *cli.Options, string, []string) error, cmd string, args []string) error {
+	switch g.backend {
+	case "aws":
+		for _, v := range g.endpoints {
+			kv := strings.SplitN(v, "=", 2)
+			if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+				return errors.New("Invalid service endpoint: " + v + ". The format is service=url")
+			}
+			if g.session.Endpoints == nil {
+				g.session.Endpoints = map[string]string{}
+			}
+			g.session.Endpoints[kv[0]] = kv[1]
+		}
+		s, err := sess.New(&g.session)
+		if err != nil {
+			return err
+		}
+		c := cli.NewClients(s)
+		c.Vars = g.values
+		return run(c, &g.options, cmd, args)
+	case "fake":
+		region := g.session.Region
+		if region == "" {
+			region = sess.DefaultRegion
+		}
+		b, err := fake.Open(g.fakeState, region)
+		if err != nil {
+			return err
+		}
+		c := b.Clients()
+		c.Vars = g.values
+		err = run(c, &g.options, cmd, args)
+		if serr := b.Save(); serr != nil && err == nil {
+			err = serr
+		}
+		return err
+	}
+	return errors.New("Unknown backend: " + g.backend + ". Possible values: aws, fake")
+}
+
+// ecs [global parameters] cmd [args...]
 // ecs help cmd
 func main() {
+	var g globals
+	global := globalParams(&g)
+	global.Parse(os.Args[1:])
+	args := global.Args()
+	if err := loadVars(&g); err != nil {
+		fmt.Fprintf(os.Stdout, err.Error()+"\n")
+		os.Exit(1)
+	}
+	if err := applyConfig(&g, global); err != nil {
+		fmt.Fprintf(os.Stdout, err.Error()+"\n")
+		os.Exit(1)
+	}
 
 	cmd := "help"
-	if len(os.Args) > 1 {
-		cmd = os.Args[1]
-		if cmd == "help" && len(os.Args) == 3 {
-			cmd = os.Args[2]
+	if len(args) > 0 {
+		cmd = args[0]
+		if cmd == "help" && len(args) == 2 {
+			cmd = args[1]
 		}
 	}
 
-	var ret []*string
-	var err error
+	var run func(*cli.Clients, *cli.Options, string, []string) error
 
 	switch {
 	case cmd == "cluster":
-		ret, err = cluster.Run(cmd, os.Args[2:])
+		run = cluster.Run
+	case cmd == "deploy":
+		run = deploy.Run
+	case cmd == "service":
+		run = service.Run
 	case cmd == "task":
-		ret, err = task.Run(cmd, os.Args[2:])
+		run = task.Run
 	case cmd == "help":
-		printHelp()
+		printHelp(global)
 		return
 	default:
 		os.Exit(1)
 	}
 
-	if err != nil {
+	if err := runCommand(&g, run, cmd, args[1:]); err != nil {
 		fmt.Fprintf(os.Stdout, err.Error()+"\n")
 		os.Exit(1)
 	}
-	for _, v := range ret {
-		fmt.Println(*v)
-	}
 
 }
@@ -0,0 +1,53 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// ec2API Serves the EC2 instances of the container instances of a backend.
+// The calls not implemented here panic.
+type ec2API struct {
+	ec2iface.EC2API
+	b *Backend
+}
+
+// DescribeInstances Describes the EC2 instances by ID, one reservation per instance
+func (e *ec2API) DescribeInstances(in *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	e.b.mu.Lock()
+	defer e.b.mu.Unlock()
+	e.b.advance()
+	out := &ec2.DescribeInstancesOutput{}
+	for _, id := range in.InstanceIds {
+		i := e.b.ec2Instance(*id)
+		if i == nil {
+			return nil, awserr.New("InvalidInstanceID.NotFound", "The instance ID '"+*id+"' does not exist", nil)
+		}
+		out.Reservations = append(out.Reservations, &ec2.Reservation{
+			Instances: []*ec2.Instance{
+				{
+					InstanceId:       aws.String(i.Ec2InstanceID),
+					PublicDnsName:    aws.String(i.PublicDNSName),
+					PublicIpAddress:  aws.String(i.PublicIPAddress),
+					PrivateIpAddress: aws.String(i.PrivateIPAddress),
+					State:            &ec2.InstanceState{Code: aws.Int64(16), Name: aws.String("running")},
+				},
+			},
+		})
+	}
+	return out, nil
+}
+
+// ec2Instance Returns the container instance running on the EC2 instance, or nil
+func (b *Backend) ec2Instance(id string) *Instance {
+	for _, c := range b.state.Clusters {
+		for _, i := range c.Instances {
+			if i.Ec2InstanceID == id {
+				return i
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,641 @@
+package fake
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// The first host port assigned to the port mappings without a host port
+const ephemeralPort = 32768
+
+// CreateCluster Creates a cluster, or returns the existing one with the same name
+func (b *Backend) CreateCluster(in *ecs.CreateClusterInput) (*ecs.CreateClusterOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	name := aws.StringValue(in.ClusterName)
+	if name == "" {
+		name = "default"
+	}
+	c := b.cluster(name)
+	if c == nil {
+		c = &Cluster{Name: name}
+		b.state.Clusters = append(b.state.Clusters, c)
+	}
+	c.Status = "ACTIVE"
+	return &ecs.CreateClusterOutput{Cluster: b.describeCluster(c)}, nil
+}
+
+// DeleteCluster Makes a cluster without container instances INACTIVE
+func (b *Backend) DeleteCluster(in *ecs.DeleteClusterInput) (*ecs.DeleteClusterOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Instances) > 0 {
+		return nil, awserr.New("ClusterContainsContainerInstancesException", "The Cluster cannot be deleted while Container Instances are active.", nil)
+	}
+	c.Status = "INACTIVE"
+	return &ecs.DeleteClusterOutput{Cluster: b.describeCluster(c)}, nil
+}
+
+// DescribeClusters Describes the clusters
+func (b *Backend) DescribeClusters(in *ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	refs := in.Clusters
+	if len(refs) == 0 {
+		refs = []*string{aws.String("default")}
+	}
+	out := &ecs.DescribeClustersOutput{}
+	for _, ref := range refs {
+		if c := b.cluster(*ref); c != nil {
+			out.Clusters = append(out.Clusters, b.describeCluster(c))
+		} else {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: ref, Reason: aws.String("MISSING")})
+		}
+	}
+	return out, nil
+}
+
+// ListClusters Lists the ACTIVE clusters
+func (b *Backend) ListClusters(in *ecs.ListClustersInput) (*ecs.ListClustersOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	var arns []string
+	for _, c := range b.state.Clusters {
+		if c.Status == "ACTIVE" {
+			arns = append(arns, b.arn("cluster/"+c.Name))
+		}
+	}
+	page, next, err := paginate(arns, in.MaxResults, in.NextToken)
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.ListClustersOutput{ClusterArns: page, NextToken: next}, nil
+}
+
+// ListContainerInstances Lists the container instances of a cluster
+func (b *Backend) ListContainerInstances(in *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	var arns []string
+	for _, i := range c.Instances {
+		arns = append(arns, b.arn("container-instance/"+i.ID))
+	}
+	page, next, err := paginate(arns, in.MaxResults, in.NextToken)
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.ListContainerInstancesOutput{ContainerInstanceArns: page, NextToken: next}, nil
+}
+
+// DescribeContainerInstances Describes the container instances of a cluster with their remaining resources
+func (b *Backend) DescribeContainerInstances(in *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	out := &ecs.DescribeContainerInstancesOutput{}
+	for _, ref := range in.ContainerInstances {
+		i := instance(c, *ref)
+		if i == nil {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: ref, Reason: aws.String("MISSING")})
+			continue
+		}
+		out.ContainerInstances = append(out.ContainerInstances, b.describeInstance(i))
+	}
+	return out, nil
+}
+
+// RegisterTaskDefinition Registers the next revision of a family
+func (b *Backend) RegisterTaskDefinition(in *ecs.RegisterTaskDefinitionInput) (*ecs.RegisterTaskDefinitionOutput, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	var revision int64 = 1
+	for _, td := range b.state.TaskDefinitions {
+		if *td.Family == *in.Family && *td.Revision >= revision {
+			revision = *td.Revision + 1
+		}
+	}
+	td := &ecs.TaskDefinition{
+		Family:               in.Family,
+		Revision:             aws.Int64(revision),
+		Status:               aws.String("ACTIVE"),
+		TaskDefinitionArn:    aws.String(b.arn("task-definition/" + *in.Family + ":" + strconv.FormatInt(revision, 10))),
+		ContainerDefinitions: in.ContainerDefinitions,
+		Volumes:              in.Volumes,
+		NetworkMode:          in.NetworkMode,
+		TaskRoleArn:          in.TaskRoleArn,
+		PlacementConstraints: in.PlacementConstraints,
+	}
+	td = awsutil.CopyOf(td).(*ecs.TaskDefinition)
+	b.state.TaskDefinitions = append(b.state.TaskDefinitions, td)
+	return &ecs.RegisterTaskDefinitionOutput{TaskDefinition: awsutil.CopyOf(td).(*ecs.TaskDefinition)}, nil
+}
+
+// DescribeTaskDefinition Describes a task definition revision
+func (b *Backend) DescribeTaskDefinition(in *ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	td, err := b.findTaskDefinition(aws.StringValue(in.TaskDefinition))
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.DescribeTaskDefinitionOutput{TaskDefinition: awsutil.CopyOf(td).(*ecs.TaskDefinition)}, nil
+}
+
+// ListTaskDefinitions Lists the task definition revisions by family and status
+func (b *Backend) ListTaskDefinitions(in *ecs.ListTaskDefinitionsInput) (*ecs.ListTaskDefinitionsOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	status := aws.StringValue(in.Status)
+	if status == "" {
+		status = "ACTIVE"
+	}
+	var tds []*ecs.TaskDefinition
+	for _, td := range b.state.TaskDefinitions {
+		if *td.Status == status && (in.FamilyPrefix == nil || *td.Family == *in.FamilyPrefix) {
+			tds = append(tds, td)
+		}
+	}
+	desc := aws.StringValue(in.Sort) == "DESC"
+	sort.SliceStable(tds, func(i, j int) bool {
+		if *tds[i].Family != *tds[j].Family {
+			return *tds[i].Family < *tds[j].Family != desc
+		}
+		return *tds[i].Revision < *tds[j].Revision != desc
+	})
+	var arns = make([]string, len(tds))
+	for i, td := range tds {
+		arns[i] = *td.TaskDefinitionArn
+	}
+	page, next, err := paginate(arns, in.MaxResults, in.NextToken)
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.ListTaskDefinitionsOutput{TaskDefinitionArns: page, NextToken: next}, nil
+}
+
+// DeregisterTaskDefinition Marks a task definition revision INACTIVE
+func (b *Backend) DeregisterTaskDefinition(in *ecs.DeregisterTaskDefinitionInput) (*ecs.DeregisterTaskDefinitionOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	ref := aws.StringValue(in.TaskDefinition)
+	if !strings.Contains(ref, ":") {
+		return nil, awserr.New("ClientException", "A revision must be specified to deregister a task definition.", nil)
+	}
+	td, err := b.findTaskDefinition(ref)
+	if err != nil {
+		return nil, err
+	}
+	td.Status = aws.String("INACTIVE")
+	return &ecs.DeregisterTaskDefinitionOutput{TaskDefinition: awsutil.CopyOf(td).(*ecs.TaskDefinition)}, nil
+}
+
+// ListTasks Lists the tasks of a cluster
+func (b *Backend) ListTasks(in *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	desired := aws.StringValue(in.DesiredStatus)
+	if desired == "" {
+		desired = "RUNNING"
+	}
+	var ci *Instance
+	if in.ContainerInstance != nil {
+		if ci = instance(c, *in.ContainerInstance); ci == nil {
+			return nil, awserr.New("InvalidParameterException", "The referenced container instance was not found.", nil)
+		}
+	}
+	var arns []string
+	for _, t := range b.tasks(c) {
+		switch {
+		case *t.DesiredStatus != desired:
+		case ci != nil && *t.ContainerInstanceArn != b.arn("container-instance/"+ci.ID):
+		case in.Family != nil && aws.StringValue(t.Group) != "family:"+*in.Family:
+		case in.StartedBy != nil && aws.StringValue(t.StartedBy) != *in.StartedBy:
+		case in.ServiceName != nil && aws.StringValue(t.Group) != "service:"+*in.ServiceName:
+		default:
+			arns = append(arns, *t.TaskArn)
+		}
+	}
+	page, next, err := paginate(arns, in.MaxResults, in.NextToken)
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.ListTasksOutput{TaskArns: page, NextToken: next}, nil
+}
+
+// DescribeTasks Describes the tasks of a cluster
+func (b *Backend) DescribeTasks(in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	out := &ecs.DescribeTasksOutput{}
+	for _, ref := range in.Tasks {
+		if t := b.task(c, *ref); t != nil {
+			out.Tasks = append(out.Tasks, awsutil.CopyOf(t).(*ecs.Task))
+		} else {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: ref, Reason: aws.String("MISSING")})
+		}
+	}
+	return out, nil
+}
+
+// StartTask Starts a task on each container instance which has enough cpu, memory and free host ports for it
+func (b *Backend) StartTask(in *ecs.StartTaskInput) (*ecs.StartTaskOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	td, err := b.findTaskDefinition(aws.StringValue(in.TaskDefinition))
+	if err != nil {
+		return nil, err
+	}
+	if len(in.ContainerInstances) == 0 || len(in.ContainerInstances) > 10 {
+		return nil, awserr.New("InvalidParameterException", "Between 1 and 10 container instances must be specified.", nil)
+	}
+	out := &ecs.StartTaskOutput{}
+	for _, ref := range in.ContainerInstances {
+		i := instance(c, *ref)
+		if i == nil {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: ref, Reason: aws.String("MISSING")})
+			continue
+		}
+		arn := b.arn("container-instance/" + i.ID)
+		if reason := b.place(i, td); reason != "" {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: aws.String(arn), Reason: aws.String(reason)})
+			continue
+		}
+		t := b.newTask(c, i, td, in)
+		b.state.Tasks = append(b.state.Tasks, t)
+		out.Tasks = append(out.Tasks, awsutil.CopyOf(t).(*ecs.Task))
+	}
+	return out, nil
+}
+
+// StopTask Sets the desired status of a task to STOPPED. It stops after StopDuration.
+func (b *Backend) StopTask(in *ecs.StopTaskInput) (*ecs.StopTaskOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	t := b.task(c, aws.StringValue(in.Task))
+	if t == nil {
+		return nil, awserr.New("InvalidParameterException", "The referenced task was not found.", nil)
+	}
+	if *t.DesiredStatus != "STOPPED" {
+		reason := aws.StringValue(in.Reason)
+		if reason == "" {
+			reason = "Task stopped by user"
+		}
+		stopTask(t, b.state.Now, reason)
+	}
+	return &ecs.StopTaskOutput{Task: awsutil.CopyOf(t).(*ecs.Task)}, nil
+}
+
+// newTask Returns a PENDING task of the task definition on the instance
+func (b *Backend) newTask(c *Cluster, i *Instance, td *ecs.TaskDefinition, in *ecs.StartTaskInput) *ecs.Task {
+	arn := b.arn("task/" + b.newID())
+	t := &ecs.Task{
+		TaskArn:              aws.String(arn),
+		ClusterArn:           aws.String(b.arn("cluster/" + c.Name)),
+		ContainerInstanceArn: aws.String(b.arn("container-instance/" + i.ID)),
+		TaskDefinitionArn:    td.TaskDefinitionArn,
+		DesiredStatus:        aws.String("RUNNING"),
+		CreatedAt:            timePtr(b.state.Now),
+		StartedBy:            in.StartedBy,
+		Overrides:            in.Overrides,
+		Group:                aws.String("family:" + *td.Family),
+	}
+	used := b.usedPorts(i)
+	next := int64(ephemeralPort)
+	for _, cd := range td.ContainerDefinitions {
+		container := &ecs.Container{
+			ContainerArn: aws.String(b.arn("container/" + b.newID())),
+			TaskArn:      t.TaskArn,
+			Name:         cd.Name,
+		}
+		for _, pm := range cd.PortMappings {
+			host := aws.Int64Value(pm.HostPort)
+			if host == 0 {
+				for used[portKey(next, pm.Protocol)] {
+					next++
+				}
+				host = next
+			}
+			used[portKey(host, pm.Protocol)] = true
+			container.NetworkBindings = append(container.NetworkBindings, &ecs.NetworkBinding{
+				BindIP:        aws.String("0.0.0.0"),
+				ContainerPort: pm.ContainerPort,
+				HostPort:      aws.Int64(host),
+				Protocol:      aws.String(protocol(pm.Protocol)),
+			})
+		}
+		t.Containers = append(t.Containers, container)
+	}
+	setStatus(t, "PENDING")
+	return t
+}
+
+// place Returns the reason why the task definition can not be placed on the instance, or an empty string if it can
+func (b *Backend) place(i *Instance, td *ecs.TaskDefinition) string {
+	cpu, memory := i.CPU, i.Memory
+	for _, t := range b.state.Tasks {
+		if *t.LastStatus != "STOPPED" && aws.StringValue(t.ContainerInstanceArn) == b.arn("container-instance/"+i.ID) {
+			if running, err := b.findTaskDefinition(*t.TaskDefinitionArn); err == nil {
+				c, m := requirements(running)
+				cpu, memory = cpu-c, memory-m
+			}
+		}
+	}
+	c, m := requirements(td)
+	if c > cpu {
+		return "RESOURCE:CPU"
+	}
+	if m > memory {
+		return "RESOURCE:MEMORY"
+	}
+	used := b.usedPorts(i)
+	for _, cd := range td.ContainerDefinitions {
+		for _, pm := range cd.PortMappings {
+			if aws.Int64Value(pm.HostPort) != 0 && used[portKey(*pm.HostPort, pm.Protocol)] {
+				return "RESOURCE:PORTS"
+			}
+		}
+	}
+	return ""
+}
+
+// requirements Returns the cpu units and the memory reserved by the containers of a task definition
+func requirements(td *ecs.TaskDefinition) (cpu int64, memory int64) {
+	for _, cd := range td.ContainerDefinitions {
+		cpu += aws.Int64Value(cd.Cpu)
+		if cd.Memory != nil {
+			memory += *cd.Memory
+		} else {
+			memory += aws.Int64Value(cd.MemoryReservation)
+		}
+	}
+	return cpu, memory
+}
+
+// usedPorts Returns the host ports bound by the tasks on the instance
+func (b *Backend) usedPorts(i *Instance) map[string]bool {
+	used := map[string]bool{}
+	for _, t := range b.state.Tasks {
+		if *t.LastStatus == "STOPPED" || aws.StringValue(t.ContainerInstanceArn) != b.arn("container-instance/"+i.ID) {
+			continue
+		}
+		for _, c := range t.Containers {
+			for _, nb := range c.NetworkBindings {
+				used[portKey(*nb.HostPort, nb.Protocol)] = true
+			}
+		}
+	}
+	return used
+}
+
+func portKey(port int64, proto *string) string {
+	return strconv.FormatInt(port, 10) + "/" + protocol(proto)
+}
+
+func protocol(proto *string) string {
+	if proto == nil {
+		return "tcp"
+	}
+	return *proto
+}
+
+// stopTask Sets the desired status of a task to STOPPED
+func stopTask(t *ecs.Task, now time.Time, reason string) {
+	t.DesiredStatus = aws.String("STOPPED")
+	t.StoppingAt = timePtr(now)
+	t.StoppedReason = aws.String(reason)
+}
+
+// setStatus Sets the last status of a task and its containers
+func setStatus(t *ecs.Task, status string) {
+	t.LastStatus = aws.String(status)
+	for _, c := range t.Containers {
+		c.LastStatus = aws.String(status)
+	}
+}
+
+// describeCluster Returns the ECS representation of a cluster
+func (b *Backend) describeCluster(c *Cluster) *ecs.Cluster {
+	out := &ecs.Cluster{
+		ClusterArn:                        aws.String(b.arn("cluster/" + c.Name)),
+		ClusterName:                       aws.String(c.Name),
+		Status:                            aws.String(c.Status),
+		RegisteredContainerInstancesCount: aws.Int64(int64(len(c.Instances))),
+		RunningTasksCount:                 aws.Int64(0),
+		PendingTasksCount:                 aws.Int64(0),
+		ActiveServicesCount:               aws.Int64(0),
+	}
+	for _, t := range b.tasks(c) {
+		switch *t.LastStatus {
+		case "RUNNING":
+			*out.RunningTasksCount++
+		case "PENDING":
+			*out.PendingTasksCount++
+		}
+	}
+	return out
+}
+
+// describeInstance Returns the ECS representation of a container instance
+func (b *Backend) describeInstance(i *Instance) *ecs.ContainerInstance {
+	arn := b.arn("container-instance/" + i.ID)
+	cpu, memory := i.CPU, i.Memory
+	var running, pending int64
+	var ports []*string
+	for _, t := range b.state.Tasks {
+		if *t.LastStatus == "STOPPED" || *t.ContainerInstanceArn != arn {
+			continue
+		}
+		if *t.LastStatus == "RUNNING" {
+			running++
+		} else {
+			pending++
+		}
+		if td, err := b.findTaskDefinition(*t.TaskDefinitionArn); err == nil {
+			c, m := requirements(td)
+			cpu, memory = cpu-c, memory-m
+		}
+		for _, c := range t.Containers {
+			for _, nb := range c.NetworkBindings {
+				ports = append(ports, aws.String(strconv.FormatInt(*nb.HostPort, 10)))
+			}
+		}
+	}
+	return &ecs.ContainerInstance{
+		ContainerInstanceArn: aws.String(arn),
+		Ec2InstanceId:        aws.String(i.Ec2InstanceID),
+		Status:               aws.String("ACTIVE"),
+		AgentConnected:       aws.Bool(true),
+		RunningTasksCount:    aws.Int64(running),
+		PendingTasksCount:    aws.Int64(pending),
+		RegisteredResources:  resources(i.CPU, i.Memory, nil),
+		RemainingResources:   resources(cpu, memory, ports),
+	}
+}
+
+func resources(cpu int64, memory int64, ports []*string) []*ecs.Resource {
+	return []*ecs.Resource{
+		{Name: aws.String("CPU"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(cpu)},
+		{Name: aws.String("MEMORY"), Type: aws.String("INTEGER"), IntegerValue: aws.Int64(memory)},
+		{Name: aws.String("PORTS"), Type: aws.String("STRINGSET"), StringSetValue: ports},
+	}
+}
+
+// cluster Returns the cluster by name or ARN, or nil
+func (b *Backend) cluster(ref string) *Cluster {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	for _, c := range b.state.Clusters {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// findCluster Returns the ACTIVE cluster by name or ARN. The default cluster is used if ref is nil.
+func (b *Backend) findCluster(ref *string) (*Cluster, error) {
+	name := aws.StringValue(ref)
+	if name == "" {
+		name = "default"
+	}
+	c := b.cluster(name)
+	if c == nil || c.Status != "ACTIVE" {
+		return nil, awserr.New("ClusterNotFoundException", "Cluster not found.", nil)
+	}
+	return c, nil
+}
+
+// instance Returns the container instance of the cluster by ID or ARN, or nil
+func instance(c *Cluster, ref string) *Instance {
+	id := ref[strings.LastIndex(ref, "/")+1:]
+	for _, i := range c.Instances {
+		if i.ID == id {
+			return i
+		}
+	}
+	return nil
+}
+
+// tasks Returns the tasks of the cluster
+func (b *Backend) tasks(c *Cluster) []*ecs.Task {
+	arn := b.arn("cluster/" + c.Name)
+	var ret []*ecs.Task
+	for _, t := range b.state.Tasks {
+		if *t.ClusterArn == arn {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// task Returns the task of the cluster by ID or ARN, or nil
+func (b *Backend) task(c *Cluster, ref string) *ecs.Task {
+	id := ref[strings.LastIndex(ref, "/")+1:]
+	for _, t := range b.tasks(c) {
+		if strings.HasSuffix(*t.TaskArn, "/"+id) {
+			return t
+		}
+	}
+	return nil
+}
+
+// findTaskDefinition Returns a task definition by ARN, family:revision or family. The latest ACTIVE revision is used if the revision is missing.
+func (b *Backend) findTaskDefinition(ref string) (*ecs.TaskDefinition, error) {
+	ref = ref[strings.LastIndex(ref, "/")+1:]
+	family, revision := ref, int64(0)
+	if i := strings.LastIndex(ref, ":"); i >= 0 {
+		family = ref[:i]
+		r, err := strconv.ParseInt(ref[i+1:], 10, 64)
+		if err != nil {
+			return nil, awserr.New("ClientException", "Invalid revision number. Number: "+ref[i+1:], nil)
+		}
+		revision = r
+	}
+	var found *ecs.TaskDefinition
+	for _, td := range b.state.TaskDefinitions {
+		if *td.Family != family {
+			continue
+		}
+		if revision != 0 && *td.Revision == revision {
+			return td, nil
+		}
+		if revision == 0 && *td.Status == "ACTIVE" && (found == nil || *td.Revision > *found.Revision) {
+			found = td
+		}
+	}
+	if found == nil {
+		return nil, awserr.New("ClientException", "Unable to describe task definition.", nil)
+	}
+	return found, nil
+}
+
+// paginate Returns a page of the items and the token of the next page
+func paginate(items []string, max *int64, token *string) ([]*string, *string, error) {
+	start := 0
+	if token != nil {
+		n, err := strconv.Atoi(*token)
+		if err != nil || n < 0 || n > len(items) {
+			return nil, nil, awserr.New("InvalidParameterException", "Invalid nextToken.", nil)
+		}
+		start = n
+	}
+	end := len(items)
+	if max != nil && *max > 0 && start+int(*max) < end {
+		end = start + int(*max)
+	}
+	var next *string
+	if end < len(items) {
+		next = aws.String(strconv.Itoa(end))
+	}
+	return aws.StringSlice(items[start:end]), next, nil
+}
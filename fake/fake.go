@@ -0,0 +1,427 @@
+// Package fake An in-memory ECS and EC2 backend for offline development and tests.
+// Tasks move from PENDING to RUNNING and from RUNNING to STOPPED on a simulated clock,
+// which advances a tick at every API call. The tasks of the services are started and replaced at every tick
+// as the ECS service scheduler does. The state can be seeded from and saved to a JSON or YAML file.
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+	"github.com/ghodss/yaml"
+)
+
+// Account The AWS account ID of the fake resources
+const Account = "000000000000"
+
+// The simulated time it takes to change the status of a task
+const (
+	Tick            = time.Second
+	PendingDuration = 2 * Tick
+	StopDuration    = Tick
+)
+
+// State The clusters, task definitions, tasks and services of the backend
+type State struct {
+	// Now The current time of the simulated clock
+	Now time.Time
+	// NextID The sequence of the generated IDs
+	NextID int64
+	// Clusters The clusters and their container instances
+	Clusters []*Cluster
+	// TaskDefinitions All the registered task definition revisions
+	TaskDefinitions []*ecs.TaskDefinition
+	// Tasks All the started tasks
+	Tasks []*ecs.Task
+	// Services All the created services, including the INACTIVE ones
+	Services []*ecs.Service
+	// Failing The images whose tasks stop instead of running, with the reason, e.g. Task failed container health checks
+	Failing map[string]string
+}
+
+// Cluster An ECS cluster
+type Cluster struct {
+	Name      string
+	Status    string
+	Instances []*Instance
+}
+
+// Instance A container instance and the EC2 instance it runs on
+type Instance struct {
+	// ID The ID of the container instance
+	ID string
+	// Ec2InstanceID The ID of the EC2 instance
+	Ec2InstanceID string
+	// CPU The registered cpu units
+	CPU int64
+	// Memory The registered memory in MiB
+	Memory           int64
+	PublicDNSName    string
+	PublicIPAddress  string
+	PrivateIPAddress string
+}
+
+// DefaultState Returns the state used when there is no seed file: a default cluster with two container instances
+func DefaultState() *State {
+	return &State{
+		Now: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+		Clusters: []*Cluster{
+			{
+				Name:   "default",
+				Status: "ACTIVE",
+				Instances: []*Instance{
+					{ID: "00000000-0000-0000-0000-0000000000a1", Ec2InstanceID: "i-0000000a1", CPU: 2048, Memory: 3768, PublicIPAddress: "198.51.100.1", PrivateIPAddress: "10.0.0.1"},
+					{ID: "00000000-0000-0000-0000-0000000000a2", Ec2InstanceID: "i-0000000a2", CPU: 2048, Memory: 3768, PublicIPAddress: "198.51.100.2", PrivateIPAddress: "10.0.0.2"},
+				},
+			},
+		},
+	}
+}
+
+// Backend An in-memory implementation of the ECS API calls used by the commands.
+// The other calls of the interface panic.
+type Backend struct {
+	ecsiface.ECSAPI
+	mu     sync.Mutex
+	region string
+	path   string
+	state  *State
+}
+
+// New Returns a backend with the given state. It panics if the state is invalid: Open reports the errors of the seed files.
+func New(state *State, region string) *Backend {
+	b := &Backend{state: state, region: region}
+	if err := b.normalize(); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Open Returns a backend seeded from the state file. If path is empty or the file does not exist, the default state is used.
+// Save writes the state back to the file.
+func Open(path string, region string) (*Backend, error) {
+	state := DefaultState()
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, err
+		default:
+			state = &State{}
+			if err := yaml.Unmarshal(data, state); err != nil {
+				return nil, fmt.Errorf("Invalid fake state file %s: %s", path, err)
+			}
+		}
+	}
+	b := &Backend{state: state, region: region, path: path}
+	if err := b.normalize(); err != nil {
+		return nil, fmt.Errorf("Invalid fake state file %s: %s", path, err)
+	}
+	return b, nil
+}
+
+// Save Writes the state to the file the backend was opened from. YAML is written unless the file name ends with .json.
+func (b *Backend) Save() error {
+	if b.path == "" {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return cli.WriteFile(b.path, b.state)
+}
+
+// State Returns the current state of the backend
+func (b *Backend) State() *State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Clients Returns the ECS and EC2 clients served by the backend
+func (b *Backend) Clients() *cli.Clients {
+	return &cli.Clients{
+		ECS:    b,
+		EC2:    &ec2API{b: b},
+		Region: b.region,
+	}
+}
+
+// normalize Fills in the fields a seed file may leave out, and returns an error if a required one is missing.
+// The task definitions, clusters and container instances of the tasks and services may be given by name.
+func (b *Backend) normalize() error {
+	if b.state.Now.IsZero() {
+		b.state.Now = time.Now().UTC()
+	}
+	for n, c := range b.state.Clusters {
+		if c.Name == "" {
+			return fmt.Errorf("The cluster %d has no name", n)
+		}
+		if c.Status == "" {
+			c.Status = "ACTIVE"
+		}
+		for _, i := range c.Instances {
+			if i.ID == "" {
+				i.ID = b.newID()
+			}
+			if i.Ec2InstanceID == "" {
+				id := strings.Replace(i.ID, "-", "", -1)
+				if len(id) > 8 {
+					id = id[len(id)-8:]
+				}
+				i.Ec2InstanceID = "i-" + id
+			}
+			if i.PublicDNSName == "" {
+				i.PublicDNSName = i.Ec2InstanceID + ".compute.fake"
+			}
+			if i.CPU == 0 && i.Memory == 0 {
+				i.CPU, i.Memory = 2048, 3768
+			}
+		}
+	}
+	if err := b.normalizeTaskDefinitions(); err != nil {
+		return err
+	}
+	for n, t := range b.state.Tasks {
+		if err := b.normalizeTask(t); err != nil {
+			return fmt.Errorf("The task %d is invalid: %s", n, err)
+		}
+	}
+	for n, s := range b.state.Services {
+		if err := b.normalizeService(s); err != nil {
+			return fmt.Errorf("The service %d is invalid: %s", n, err)
+		}
+	}
+	return nil
+}
+
+// normalizeTaskDefinitions Fills in the family, the revision, the ARN and the status of the task definitions.
+// The family and the revision are read from the ARN, and the missing revisions follow the latest one of the family.
+func (b *Backend) normalizeTaskDefinitions() error {
+	latest := map[string]int64{}
+	for n, td := range b.state.TaskDefinitions {
+		if td.TaskDefinitionArn != nil {
+			ref := *td.TaskDefinitionArn
+			ref = ref[strings.LastIndex(ref, "/")+1:]
+			i := strings.LastIndex(ref, ":")
+			if i < 0 {
+				return fmt.Errorf("The task definition %d has no revision in its ARN %s", n, *td.TaskDefinitionArn)
+			}
+			revision, err := strconv.ParseInt(ref[i+1:], 10, 64)
+			if err != nil {
+				return fmt.Errorf("The task definition %d has an invalid revision in its ARN %s", n, *td.TaskDefinitionArn)
+			}
+			td.Family, td.Revision = aws.String(ref[:i]), aws.Int64(revision)
+		}
+		if aws.StringValue(td.Family) == "" {
+			return fmt.Errorf("The task definition %d has neither family nor ARN", n)
+		}
+		if r := aws.Int64Value(td.Revision); r > latest[*td.Family] {
+			latest[*td.Family] = r
+		}
+	}
+	for _, td := range b.state.TaskDefinitions {
+		if td.Revision == nil {
+			latest[*td.Family]++
+			td.Revision = aws.Int64(latest[*td.Family])
+		}
+		td.TaskDefinitionArn = aws.String(b.arn("task-definition/" + *td.Family + ":" + strconv.FormatInt(*td.Revision, 10)))
+		if td.Status == nil {
+			td.Status = aws.String("ACTIVE")
+		}
+	}
+	return nil
+}
+
+// normalizeTask Fills in the ARNs and the status of a task. A task without container instance runs on the first one of its cluster.
+func (b *Backend) normalizeTask(t *ecs.Task) error {
+	if t.TaskDefinitionArn == nil {
+		return errors.New("the task definition is required")
+	}
+	td, err := b.findTaskDefinition(*t.TaskDefinitionArn)
+	if err != nil {
+		return errors.New("there is no task definition " + *t.TaskDefinitionArn)
+	}
+	t.TaskDefinitionArn = td.TaskDefinitionArn
+	c := b.seedCluster(t.ClusterArn)
+	if c == nil {
+		return errors.New("there is no cluster " + aws.StringValue(t.ClusterArn))
+	}
+	t.ClusterArn = aws.String(b.arn("cluster/" + c.Name))
+	if t.ContainerInstanceArn == nil {
+		if len(c.Instances) == 0 {
+			return errors.New("the cluster " + c.Name + " has no container instance")
+		}
+		t.ContainerInstanceArn = aws.String(c.Instances[0].ID)
+	}
+	i := instance(c, *t.ContainerInstanceArn)
+	if i == nil {
+		return errors.New("there is no container instance " + *t.ContainerInstanceArn + " in the cluster " + c.Name)
+	}
+	t.ContainerInstanceArn = aws.String(b.arn("container-instance/" + i.ID))
+	if t.TaskArn == nil {
+		t.TaskArn = aws.String(b.arn("task/" + b.newID()))
+	}
+	if t.DesiredStatus == nil {
+		t.DesiredStatus = aws.String("RUNNING")
+		if aws.StringValue(t.LastStatus) == "STOPPED" {
+			t.DesiredStatus = aws.String("STOPPED")
+		}
+	}
+	if t.LastStatus == nil {
+		t.LastStatus = aws.String(*t.DesiredStatus)
+	}
+	if t.CreatedAt == nil {
+		t.CreatedAt = timePtr(b.state.Now)
+	}
+	if t.Group == nil {
+		t.Group = aws.String("family:" + *td.Family)
+	}
+	for _, container := range t.Containers {
+		for _, nb := range container.NetworkBindings {
+			if nb.HostPort == nil {
+				return errors.New("a network binding of the container " + aws.StringValue(container.Name) + " has no host port")
+			}
+		}
+	}
+	return nil
+}
+
+// seedCluster Returns the cluster of a seeded task or service by name or ARN, whatever its status. The default cluster is used if ref is nil.
+func (b *Backend) seedCluster(ref *string) *Cluster {
+	if ref == nil {
+		return b.cluster("default")
+	}
+	return b.cluster(*ref)
+}
+
+// normalizeService Fills in the ARNs, the counts, the deployment configuration and the deployments of a service.
+// A service without deployment gets a PRIMARY deployment of its task definition.
+func (b *Backend) normalizeService(s *ecs.Service) error {
+	if aws.StringValue(s.ServiceName) == "" {
+		return errors.New("the service name is required")
+	}
+	c := b.seedCluster(s.ClusterArn)
+	if c == nil {
+		return errors.New("there is no cluster " + aws.StringValue(s.ClusterArn))
+	}
+	s.ClusterArn = aws.String(b.arn("cluster/" + c.Name))
+	s.ServiceArn = aws.String(b.arn("service/" + c.Name + "/" + *s.ServiceName))
+	if s.TaskDefinition == nil {
+		return errors.New("the task definition is required")
+	}
+	td, err := b.findTaskDefinition(*s.TaskDefinition)
+	if err != nil {
+		return errors.New("there is no task definition " + *s.TaskDefinition)
+	}
+	s.TaskDefinition = td.TaskDefinitionArn
+	if s.Status == nil {
+		s.Status = aws.String("ACTIVE")
+	}
+	for _, count := range []**int64{&s.DesiredCount, &s.RunningCount, &s.PendingCount} {
+		if *count == nil {
+			*count = aws.Int64(0)
+		}
+	}
+	if s.DeploymentConfiguration == nil {
+		s.DeploymentConfiguration = &ecs.DeploymentConfiguration{}
+	}
+	if s.DeploymentConfiguration.MaximumPercent == nil {
+		s.DeploymentConfiguration.MaximumPercent = aws.Int64(200)
+	}
+	if s.DeploymentConfiguration.MinimumHealthyPercent == nil {
+		s.DeploymentConfiguration.MinimumHealthyPercent = aws.Int64(100)
+	}
+	if s.CreatedAt == nil {
+		s.CreatedAt = timePtr(b.state.Now)
+	}
+	if len(s.Deployments) == 0 && *s.Status != "INACTIVE" {
+		s.Deployments = []*ecs.Deployment{b.newDeployment(td, *s.DesiredCount)}
+	}
+	for n, d := range s.Deployments {
+		if d.Id == nil {
+			d.Id = aws.String("ecs-svc/" + b.newID())
+		}
+		if d.Status == nil {
+			d.Status = aws.String("ACTIVE")
+			if n == 0 {
+				d.Status = aws.String("PRIMARY")
+			}
+		}
+		if d.TaskDefinition == nil {
+			d.TaskDefinition = s.TaskDefinition
+		}
+		for _, count := range []**int64{&d.DesiredCount, &d.RunningCount, &d.PendingCount} {
+			if *count == nil {
+				*count = aws.Int64(0)
+			}
+		}
+		if d.CreatedAt == nil {
+			d.CreatedAt = timePtr(b.state.Now)
+		}
+		if d.UpdatedAt == nil {
+			d.UpdatedAt = d.CreatedAt
+		}
+	}
+	return nil
+}
+
+// newID Returns a new unique ID in the format of the ECS IDs
+func (b *Backend) newID() string {
+	b.state.NextID++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012x", b.state.NextID)
+}
+
+// arn Returns the ARN of an ECS resource
+func (b *Backend) arn(resource string) string {
+	return "arn:aws:ecs:" + b.region + ":" + Account + ":" + resource
+}
+
+// advance Moves the simulated clock a tick forward, updates the status of the tasks and schedules the tasks of the services
+func (b *Backend) advance() {
+	b.state.Now = b.state.Now.Add(Tick)
+	now := b.state.Now
+	for _, t := range b.state.Tasks {
+		switch {
+		case *t.LastStatus == "PENDING" && *t.DesiredStatus == "RUNNING" && !now.Before(t.CreatedAt.Add(PendingDuration)):
+			if reason := b.failure(t); reason != "" {
+				stopTask(t, now, reason)
+				break
+			}
+			setStatus(t, "RUNNING")
+			t.StartedAt = timePtr(now)
+		case *t.LastStatus != "STOPPED" && *t.DesiredStatus == "STOPPED" && t.StoppingAt != nil && !now.Before(t.StoppingAt.Add(StopDuration)):
+			setStatus(t, "STOPPED")
+			t.StoppedAt = timePtr(now)
+		}
+	}
+	b.schedule()
+}
+
+// failure Returns the reason why a task fails, or an empty string if its images are not failing
+func (b *Backend) failure(t *ecs.Task) string {
+	td, err := b.findTaskDefinition(*t.TaskDefinitionArn)
+	if err != nil {
+		return ""
+	}
+	for _, cd := range td.ContainerDefinitions {
+		if reason, ok := b.state.Failing[aws.StringValue(cd.Image)]; ok {
+			return reason
+		}
+	}
+	return ""
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,350 @@
+package fake
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+const instanceID = "00000000-0000-0000-0000-0000000000a1"
+
+func register(t *testing.T, b *Backend, family string, cpu int64, hostPort int64) *ecs.TaskDefinition {
+	resp, err := b.RegisterTaskDefinition(&ecs.RegisterTaskDefinitionInput{
+		Family: aws.String(family),
+		ContainerDefinitions: []*ecs.ContainerDefinition{
+			{
+				Name:   aws.String(family),
+				Image:  aws.String("nginx"),
+				Cpu:    aws.Int64(cpu),
+				Memory: aws.Int64(128),
+				PortMappings: []*ecs.PortMapping{
+					{ContainerPort: aws.Int64(80), HostPort: aws.Int64(hostPort)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.TaskDefinition
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	b := New(DefaultState(), "us-east-1")
+	register(t, b, "web", 256, 0)
+	td := register(t, b, "web", 256, 0)
+	if *td.Revision != 2 {
+		t.Errorf("second revision = %d, want 2", *td.Revision)
+	}
+
+	start, err := b.StartTask(&ecs.StartTaskInput{
+		TaskDefinition:     aws.String("web"),
+		ContainerInstances: aws.StringSlice([]string{instanceID}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(start.Failures) != 0 || len(start.Tasks) != 1 {
+		t.Fatalf("StartTask = %v", start)
+	}
+	task := start.Tasks[0]
+	if *task.TaskDefinitionArn != *td.TaskDefinitionArn {
+		t.Errorf("started %s, want the latest revision %s", *task.TaskDefinitionArn, *td.TaskDefinitionArn)
+	}
+	if *task.LastStatus != "PENDING" {
+		t.Errorf("new task is %s, want PENDING", *task.LastStatus)
+	}
+	if port := *task.Containers[0].NetworkBindings[0].HostPort; port != ephemeralPort {
+		t.Errorf("host port = %d, want %d", port, ephemeralPort)
+	}
+
+	// status Returns the status of the task after d passed on the simulated clock
+	status := func(d time.Duration) string {
+		var s string
+		for i := time.Duration(0); i < d; i += Tick {
+			resp, err := b.DescribeTasks(&ecs.DescribeTasksInput{Tasks: []*string{task.TaskArn}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s = *resp.Tasks[0].LastStatus
+		}
+		return s
+	}
+	if s := status(PendingDuration - Tick); s != "PENDING" {
+		t.Errorf("task is %s before %s, want PENDING", s, PendingDuration)
+	}
+	if s := status(Tick); s != "RUNNING" {
+		t.Errorf("task is %s after %s, want RUNNING", s, PendingDuration)
+	}
+
+	if _, err := b.StopTask(&ecs.StopTaskInput{Task: task.TaskArn}); err != nil {
+		t.Fatal(err)
+	}
+	if s := status(StopDuration); s != "STOPPED" {
+		t.Errorf("task is %s after %s, want STOPPED", s, StopDuration)
+	}
+
+	list, err := b.ListTasks(&ecs.ListTasksInput{DesiredStatus: aws.String("STOPPED"), Family: aws.String("web")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.TaskArns) != 1 {
+		t.Errorf("ListTasks STOPPED = %v", aws.StringValueSlice(list.TaskArns))
+	}
+}
+
+func TestStartTaskResources(t *testing.T) {
+	b := New(DefaultState(), "us-east-1")
+	register(t, b, "big", 2048, 0)
+	register(t, b, "port", 1, 80)
+	start := func(family string) string {
+		resp, err := b.StartTask(&ecs.StartTaskInput{
+			TaskDefinition:     aws.String(family),
+			ContainerInstances: aws.StringSlice([]string{instanceID}),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Failures) == 0 {
+			return ""
+		}
+		return *resp.Failures[0].Reason
+	}
+	if reason := start("big"); reason != "" {
+		t.Fatalf("first task failed: %s", reason)
+	}
+	if reason := start("big"); reason != "RESOURCE:CPU" {
+		t.Errorf("second task failure = %q, want RESOURCE:CPU", reason)
+	}
+
+	b = New(DefaultState(), "us-east-1")
+	register(t, b, "port", 1, 80)
+	if reason := start("port"); reason != "" {
+		t.Fatalf("first task failed: %s", reason)
+	}
+	if reason := start("port"); reason != "RESOURCE:PORTS" {
+		t.Errorf("second task failure = %q, want RESOURCE:PORTS", reason)
+	}
+}
+
+func TestClusters(t *testing.T) {
+	b := New(DefaultState(), "us-east-1")
+	if _, err := b.CreateCluster(&ecs.CreateClusterInput{ClusterName: aws.String("staging")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.DeleteCluster(&ecs.DeleteClusterInput{Cluster: aws.String("default")}); err == nil {
+		t.Error("deleted a cluster with container instances")
+	}
+	if _, err := b.DeleteCluster(&ecs.DeleteClusterInput{Cluster: aws.String("staging")}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := b.ListClusters(&ecs.ListClustersInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"arn:aws:ecs:us-east-1:" + Account + ":cluster/default"}; len(list.ClusterArns) != 1 || *list.ClusterArns[0] != want[0] {
+		t.Errorf("ListClusters = %v, want %v", aws.StringValueSlice(list.ClusterArns), want)
+	}
+}
+
+func TestDescribeInstances(t *testing.T) {
+	c := New(DefaultState(), "us-east-1").Clients()
+	ins, err := c.ECS.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{ContainerInstances: aws.StringSlice([]string{instanceID})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.EC2.DescribeInstances(&ec2.DescribeInstancesInput{InstanceIds: []*string{ins.ContainerInstances[0].Ec2InstanceId}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dns := *resp.Reservations[0].Instances[0].PublicDnsName; dns != "i-0000000a1.compute.fake" {
+		t.Errorf("public DNS name = %s", dns)
+	}
+	if _, err := c.EC2.DescribeInstances(&ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice([]string{"i-missing"})}); err == nil {
+		t.Error("described a missing instance")
+	}
+}
+
+func TestSaveAndOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.yaml")
+	seed := "Clusters:\n- Name: default\n  Instances:\n  - CPU: 1024\n    Memory: 2048\n"
+	if err := ioutil.WriteFile(path, []byte(seed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Open(path, "eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	register(t, b, "web", 256, 0)
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = Open(path, "eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := b.State()
+	if len(state.TaskDefinitions) != 1 || len(state.Clusters[0].Instances) != 1 {
+		t.Fatalf("reopened state = %v", state)
+	}
+	if i := state.Clusters[0].Instances[0]; i.ID == "" || i.CPU != 1024 {
+		t.Errorf("reopened instance = %v", i)
+	}
+}
+
+func TestServiceDeployment(t *testing.T) {
+	b := New(DefaultState(), "us-east-1")
+	register(t, b, "web", 256, 80)
+	_, err := b.CreateService(&ecs.CreateServiceInput{
+		ServiceName:             aws.String("web"),
+		TaskDefinition:          aws.String("web"),
+		DesiredCount:            aws.Int64(2),
+		DeploymentConfiguration: &ecs.DeploymentConfiguration{MinimumHealthyPercent: aws.Int64(50)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// steady Describes the service until its PRIMARY deployment runs alone
+	steady := func() *ecs.Service {
+		for i := 0; i < 20; i++ {
+			resp, err := b.DescribeServices(&ecs.DescribeServicesInput{Services: aws.StringSlice([]string{"web"})})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := resp.Services[0]
+			if len(s.Deployments) == 1 && *s.RunningCount == *s.DesiredCount && *s.PendingCount == 0 {
+				return s
+			}
+		}
+		t.Fatal("the service did not reach a steady state")
+		return nil
+	}
+	steady()
+
+	// The host port is fixed, so a new task can only be placed when an old one is stopped
+	td := register(t, b, "web", 256, 80)
+	if _, err := b.UpdateService(&ecs.UpdateServiceInput{Service: aws.String("web"), TaskDefinition: aws.String("web")}); err != nil {
+		t.Fatal(err)
+	}
+	s := steady()
+	if *s.Deployments[0].TaskDefinition != *td.TaskDefinitionArn {
+		t.Errorf("PRIMARY deployment of %s, want %s", *s.Deployments[0].TaskDefinition, *td.TaskDefinitionArn)
+	}
+	running, err := b.ListTasks(&ecs.ListTasksInput{DesiredStatus: aws.String("RUNNING")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(running.TaskArns) != 2 {
+		t.Errorf("%d running tasks, want 2", len(running.TaskArns))
+	}
+
+	if _, err := b.DeleteService(&ecs.DeleteServiceInput{Service: aws.String("web")}); err == nil {
+		t.Error("deleted a service scaled above 0")
+	}
+	if _, err := b.UpdateService(&ecs.UpdateServiceInput{Service: aws.String("web"), DesiredCount: aws.Int64(0)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.DeleteService(&ecs.DeleteServiceInput{Service: aws.String("web")}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := b.ListServices(&ecs.ListServicesInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10 && len(list.ServiceArns) > 0; i++ {
+		if list, err = b.ListServices(&ecs.ListServicesInput{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(list.ServiceArns) > 0 {
+		t.Errorf("ListServices after delete = %v", aws.StringValueSlice(list.ServiceArns))
+	}
+}
+
+func TestOpenMinimalSeed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "seed.yaml")
+	seed := `Clusters:
+- Name: default
+  Instances:
+  - ID: a1
+TaskDefinitions:
+- Family: web
+  ContainerDefinitions:
+  - Name: web
+    Image: nginx
+    Memory: 128
+- TaskDefinitionArn: arn:aws:ecs:us-east-1:000000000000:task-definition/worker:3
+Tasks:
+- TaskDefinitionArn: web:1
+Services:
+- ServiceName: web
+  TaskDefinition: web
+  DesiredCount: 1
+  DeploymentConfiguration:
+    MinimumHealthyPercent: 50
+`
+	if err := ioutil.WriteFile(path, []byte(seed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Open(path, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := b.State()
+	if i := state.Clusters[0].Instances[0]; i.Ec2InstanceID != "i-a1" || i.CPU == 0 {
+		t.Errorf("seeded instance = %+v", i)
+	}
+	if td := state.TaskDefinitions[1]; *td.Family != "worker" || *td.Revision != 3 || *td.Status != "ACTIVE" {
+		t.Errorf("task definition seeded by ARN = %v", td)
+	}
+	task := state.Tasks[0]
+	if *task.LastStatus != "RUNNING" || *task.ContainerInstanceArn != "arn:aws:ecs:us-east-1:"+Account+":container-instance/a1" || *task.TaskDefinitionArn != *state.TaskDefinitions[0].TaskDefinitionArn {
+		t.Errorf("seeded task = %v", task)
+	}
+	s := state.Services[0]
+	if *s.Deployments[0].Status != "PRIMARY" || *s.DeploymentConfiguration.MinimumHealthyPercent != 50 || *s.DeploymentConfiguration.MaximumPercent != 200 {
+		t.Errorf("seeded service = %v", s)
+	}
+
+	// The simulation runs on the seeded state
+	register(t, b, "web", 256, 0)
+	if _, err := b.ListTasks(&ecs.ListTasksInput{}); err != nil {
+		t.Fatal(err)
+	}
+	if revision := *state.TaskDefinitions[2].Revision; revision != 2 {
+		t.Errorf("registered revision %d, want 2", revision)
+	}
+
+	for _, invalid := range []string{
+		"Tasks:\n- DesiredStatus: RUNNING\n",
+		"Tasks:\n- TaskDefinitionArn: missing:1\n",
+		"TaskDefinitions:\n- Status: ACTIVE\n",
+		"Services:\n- ServiceName: web\n",
+	} {
+		if err := ioutil.WriteFile(path, []byte(invalid), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path, "us-east-1"); err == nil || !strings.HasPrefix(err.Error(), "Invalid fake state file") {
+			t.Errorf("Open of %q error = %v", invalid, err)
+		}
+	}
+}
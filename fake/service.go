@@ -0,0 +1,345 @@
+package fake
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// The number of events kept by a service
+const maxEvents = 100
+
+// CreateService Creates a service running the task definition. Its tasks are started by the scheduler at the next calls.
+func (b *Backend) CreateService(in *ecs.CreateServiceInput) (*ecs.CreateServiceOutput, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if s := b.service(c, *in.ServiceName); s != nil && *s.Status != "INACTIVE" {
+		return nil, awserr.New("InvalidParameterException", "Creation of service was not idempotent.", nil)
+	}
+	td, err := b.findTaskDefinition(aws.StringValue(in.TaskDefinition))
+	if err != nil {
+		return nil, err
+	}
+	config := &ecs.DeploymentConfiguration{MaximumPercent: aws.Int64(200), MinimumHealthyPercent: aws.Int64(100)}
+	mergeDeploymentConfiguration(config, in.DeploymentConfiguration)
+	desired := aws.Int64Value(in.DesiredCount)
+	s := &ecs.Service{
+		ServiceArn:              aws.String(b.arn("service/" + c.Name + "/" + *in.ServiceName)),
+		ServiceName:             in.ServiceName,
+		ClusterArn:              aws.String(b.arn("cluster/" + c.Name)),
+		TaskDefinition:          td.TaskDefinitionArn,
+		DesiredCount:            aws.Int64(desired),
+		RunningCount:            aws.Int64(0),
+		PendingCount:            aws.Int64(0),
+		Status:                  aws.String("ACTIVE"),
+		LoadBalancers:           in.LoadBalancers,
+		RoleArn:                 in.Role,
+		DeploymentConfiguration: config,
+		Deployments:             []*ecs.Deployment{b.newDeployment(td, desired)},
+		CreatedAt:               timePtr(b.state.Now),
+	}
+	s = awsutil.CopyOf(s).(*ecs.Service)
+	b.state.Services = append(b.state.Services, s)
+	return &ecs.CreateServiceOutput{Service: awsutil.CopyOf(s).(*ecs.Service)}, nil
+}
+
+// UpdateService Changes the desired count, the deployment configuration or the task definition of an ACTIVE service.
+// A new task definition, or a forced deployment, makes a new PRIMARY deployment.
+func (b *Backend) UpdateService(in *ecs.UpdateServiceInput) (*ecs.UpdateServiceOutput, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	s, err := b.findActiveService(in.Cluster, *in.Service)
+	if err != nil {
+		return nil, err
+	}
+	mergeDeploymentConfiguration(s.DeploymentConfiguration, in.DeploymentConfiguration)
+	if in.DesiredCount != nil {
+		s.DesiredCount = aws.Int64(*in.DesiredCount)
+		s.Deployments[0].DesiredCount = aws.Int64(*in.DesiredCount)
+	}
+	td, err := b.findTaskDefinition(aws.StringValue(s.TaskDefinition))
+	if in.TaskDefinition != nil {
+		td, err = b.findTaskDefinition(*in.TaskDefinition)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if *td.TaskDefinitionArn != *s.TaskDefinition || aws.BoolValue(in.ForceNewDeployment) {
+		s.Deployments[0].Status = aws.String("ACTIVE")
+		s.Deployments = append([]*ecs.Deployment{b.newDeployment(td, *s.DesiredCount)}, s.Deployments...)
+		s.TaskDefinition = td.TaskDefinitionArn
+	}
+	return &ecs.UpdateServiceOutput{Service: awsutil.CopyOf(s).(*ecs.Service)}, nil
+}
+
+// DeleteService Makes a service scaled to 0 DRAINING. It becomes INACTIVE when its tasks are stopped.
+func (b *Backend) DeleteService(in *ecs.DeleteServiceInput) (*ecs.DeleteServiceOutput, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	s, err := b.findActiveService(in.Cluster, *in.Service)
+	if err != nil {
+		return nil, err
+	}
+	if *s.DesiredCount > 0 && !aws.BoolValue(in.Force) {
+		return nil, awserr.New("InvalidParameterException", "The service cannot be stopped while it is scaled above 0.", nil)
+	}
+	s.Status = aws.String("DRAINING")
+	return &ecs.DeleteServiceOutput{Service: awsutil.CopyOf(s).(*ecs.Service)}, nil
+}
+
+// ListServices Lists the ACTIVE and DRAINING services of a cluster
+func (b *Backend) ListServices(in *ecs.ListServicesInput) (*ecs.ListServicesOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	arns := []string{}
+	for _, s := range b.state.Services {
+		if *s.ClusterArn == b.arn("cluster/"+c.Name) && *s.Status != "INACTIVE" {
+			arns = append(arns, *s.ServiceArn)
+		}
+	}
+	page, next, err := paginate(arns, in.MaxResults, in.NextToken)
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.ListServicesOutput{ServiceArns: page, NextToken: next}, nil
+}
+
+// DescribeServices Describes the services of a cluster by name or ARN
+func (b *Backend) DescribeServices(in *ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	c, err := b.findCluster(in.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	out := &ecs.DescribeServicesOutput{}
+	for _, ref := range in.Services {
+		if s := b.service(c, *ref); s != nil {
+			out.Services = append(out.Services, awsutil.CopyOf(s).(*ecs.Service))
+		} else {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: ref, Reason: aws.String("MISSING")})
+		}
+	}
+	return out, nil
+}
+
+// newDeployment Returns a PRIMARY deployment of the task definition
+func (b *Backend) newDeployment(td *ecs.TaskDefinition, desired int64) *ecs.Deployment {
+	return &ecs.Deployment{
+		Id:             aws.String("ecs-svc/" + b.newID()),
+		Status:         aws.String("PRIMARY"),
+		TaskDefinition: td.TaskDefinitionArn,
+		DesiredCount:   aws.Int64(desired),
+		PendingCount:   aws.Int64(0),
+		RunningCount:   aws.Int64(0),
+		CreatedAt:      timePtr(b.state.Now),
+		UpdatedAt:      timePtr(b.state.Now),
+	}
+}
+
+// mergeDeploymentConfiguration Sets the fields of the deployment configuration which are set in the update
+func mergeDeploymentConfiguration(config *ecs.DeploymentConfiguration, update *ecs.DeploymentConfiguration) {
+	if update == nil {
+		return
+	}
+	if update.MaximumPercent != nil {
+		config.MaximumPercent = aws.Int64(*update.MaximumPercent)
+	}
+	if update.MinimumHealthyPercent != nil {
+		config.MinimumHealthyPercent = aws.Int64(*update.MinimumHealthyPercent)
+	}
+}
+
+// service Returns the latest service of the cluster by name or ARN, or nil
+func (b *Backend) service(c *Cluster, ref string) *ecs.Service {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	var found *ecs.Service
+	for _, s := range b.state.Services {
+		if *s.ServiceName == name && *s.ClusterArn == b.arn("cluster/"+c.Name) {
+			found = s
+		}
+	}
+	return found
+}
+
+// findActiveService Returns the ACTIVE service of the cluster by name or ARN
+func (b *Backend) findActiveService(cluster *string, ref string) (*ecs.Service, error) {
+	c, err := b.findCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	s := b.service(c, ref)
+	if s == nil {
+		return nil, awserr.New("ServiceNotFoundException", "Service not found.", nil)
+	}
+	if *s.Status != "ACTIVE" {
+		return nil, awserr.New("ServiceNotActiveException", "Service was not ACTIVE.", nil)
+	}
+	return s, nil
+}
+
+// schedule Starts and stops the tasks of the services as the ECS service scheduler does
+func (b *Backend) schedule() {
+	for _, s := range b.state.Services {
+		if c := b.cluster(*s.ClusterArn); c != nil && *s.Status != "INACTIVE" {
+			b.scheduleService(c, s)
+		}
+	}
+}
+
+// scheduleService Starts the missing tasks of the PRIMARY deployment of a service, spread over the container instances,
+// without exceeding the maximum percent of the desired count. The tasks of the older deployments are stopped as long as
+// the running tasks stay above the minimum healthy percent, or all at once when the PRIMARY deployment is running.
+// The drained deployments are removed, and a DRAINING service without task becomes INACTIVE.
+func (b *Backend) scheduleService(c *Cluster, s *ecs.Service) {
+	primary := s.Deployments[0]
+	desired := *primary.DesiredCount
+	if *s.Status == "DRAINING" {
+		desired = 0
+	}
+	deployments := map[string]bool{}
+	for _, d := range s.Deployments {
+		deployments[*d.Id] = true
+	}
+	live := map[string][]*ecs.Task{}
+	var total, healthy int64
+	for _, t := range b.tasks(c) {
+		if *t.DesiredStatus != "RUNNING" || !deployments[aws.StringValue(t.StartedBy)] {
+			continue
+		}
+		live[*t.StartedBy] = append(live[*t.StartedBy], t)
+		total++
+		if *t.LastStatus == "RUNNING" {
+			healthy++
+		}
+	}
+	maxTotal := desired * aws.Int64Value(s.DeploymentConfiguration.MaximumPercent) / 100
+	minHealthy := (desired*aws.Int64Value(s.DeploymentConfiguration.MinimumHealthyPercent) + 99) / 100
+
+	var started, stopped []string
+	n := int64(len(live[*primary.Id]))
+	if td, err := b.findTaskDefinition(*primary.TaskDefinition); err == nil {
+		for placed := true; placed && n < desired && total < maxTotal; {
+			placed = false
+			for _, i := range c.Instances {
+				if n >= desired || total >= maxTotal || b.place(i, td) != "" {
+					continue
+				}
+				t := b.newTask(c, i, td, &ecs.StartTaskInput{StartedBy: primary.Id})
+				t.Group = aws.String("service:" + *s.ServiceName)
+				b.state.Tasks = append(b.state.Tasks, t)
+				started = append(started, "(task "+taskID(t)+")")
+				n, total, placed = n+1, total+1, true
+			}
+		}
+	}
+	if n < desired && total < maxTotal {
+		b.serviceEvent(s, "(service "+*s.ServiceName+") was unable to place a task because no container instance met all of its requirements.")
+	}
+	if tasks := live[*primary.Id]; int64(len(tasks)) > desired {
+		for _, t := range tasks[desired:] {
+			stopTask(t, b.state.Now, "Scaling activity initiated by (deployment "+*primary.Id+")")
+			stopped = append(stopped, "(task "+taskID(t)+")")
+		}
+	}
+
+	var primaryRunning int64
+	for _, t := range live[*primary.Id] {
+		if *t.LastStatus == "RUNNING" {
+			primaryRunning++
+		}
+	}
+	for _, d := range s.Deployments[1:] {
+		for _, t := range live[*d.Id] {
+			switch {
+			case *t.LastStatus != "RUNNING":
+			case primaryRunning >= desired || healthy-1 >= minHealthy:
+				healthy--
+			default:
+				continue
+			}
+			stopTask(t, b.state.Now, "Scaling activity initiated by (deployment "+*primary.Id+")")
+			stopped = append(stopped, "(task "+taskID(t)+")")
+		}
+	}
+	if len(started) > 0 {
+		b.serviceEvent(s, fmt.Sprintf("(service %s) has started %d tasks: %s.", *s.ServiceName, len(started), strings.Join(started, " ")))
+	}
+	if len(stopped) > 0 {
+		b.serviceEvent(s, fmt.Sprintf("(service %s) has stopped %d running tasks: %s.", *s.ServiceName, len(stopped), strings.Join(stopped, " ")))
+	}
+
+	var running, pending int64
+	kept := []*ecs.Deployment{primary}
+	for i, d := range s.Deployments {
+		var r, p int64
+		for _, t := range b.tasks(c) {
+			if aws.StringValue(t.StartedBy) != *d.Id || *t.LastStatus == "STOPPED" {
+				continue
+			}
+			if *t.LastStatus == "RUNNING" {
+				r++
+			} else {
+				p++
+			}
+		}
+		if r != aws.Int64Value(d.RunningCount) || p != aws.Int64Value(d.PendingCount) {
+			d.RunningCount, d.PendingCount = aws.Int64(r), aws.Int64(p)
+			d.UpdatedAt = timePtr(b.state.Now)
+		}
+		running, pending = running+r, pending+p
+		if i > 0 && r+p > 0 {
+			kept = append(kept, d)
+		}
+	}
+	s.Deployments = kept
+	s.RunningCount, s.PendingCount = aws.Int64(running), aws.Int64(pending)
+	if *s.Status == "DRAINING" && running+pending == 0 {
+		s.Status = aws.String("INACTIVE")
+	}
+	if *s.Status == "ACTIVE" && len(kept) == 1 && running == desired && pending == 0 {
+		b.serviceEvent(s, "(service "+*s.ServiceName+") has reached a steady state.")
+	}
+}
+
+// serviceEvent Adds an event to a service, unless it repeats the latest one. The latest events come first.
+func (b *Backend) serviceEvent(s *ecs.Service, message string) {
+	if len(s.Events) > 0 && *s.Events[0].Message == message {
+		return
+	}
+	e := &ecs.ServiceEvent{Id: aws.String(b.newID()), CreatedAt: timePtr(b.state.Now), Message: aws.String(message)}
+	s.Events = append([]*ecs.ServiceEvent{e}, s.Events...)
+	if len(s.Events) > maxEvents {
+		s.Events = s.Events[:maxEvents]
+	}
+}
+
+func taskID(t *ecs.Task) string {
+	return (*t.TaskArn)[strings.LastIndex(*t.TaskArn, "/")+1:]
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+// Wait How to wait for a deployment
+type Wait struct {
+	// Interval The time between two polls of the service
+	Interval time.Duration
+	// Timeout The maximum time to wait for the service to be stable
+	Timeout time.Duration
+	// Events Receives the new events of the service, if not nil
+	Events io.Writer
+	// MaxStopped The number of tasks of the PRIMARY deployment stopping in a row which fails it, or 0 to wait until the timeout
+	MaxStopped int
+}
+
+// Failure The cause of the failure of a deployment: a timeout, stopped tasks or a failed health check
+type Failure struct {
+	Message string
+}
+
+func (f *Failure) Error() string {
+	return f.Message
+}
+
+// Primary Returns the PRIMARY deployment of a service, or nil if it has none
+func Primary(s *ecs.Service) *ecs.Deployment {
+	for _, d := range s.Deployments {
+		if aws.StringValue(d.Status) == "PRIMARY" {
+			return d
+		}
+	}
+	return nil
+}
+
+// Stable Returns whether the PRIMARY deployment of a service runs its desired count of tasks and the older deployments are drained
+func Stable(s *ecs.Service) bool {
+	d := Primary(s)
+	if d == nil || len(s.Deployments) != 1 {
+		return false
+	}
+	return aws.Int64Value(d.RunningCount) == aws.Int64Value(d.DesiredCount) && aws.Int64Value(d.PendingCount) == 0
+}
+
+// deploymentTasks Returns the tasks started by a deployment with the given desired status
+func deploymentTasks(svc ecsiface.ECSAPI, cluster *string, d *ecs.Deployment, status string) ([]*ecs.Task, error) {
+	var arns []*string
+	params := &ecs.ListTasksInput{Cluster: cluster, StartedBy: d.Id, DesiredStatus: aws.String(status)}
+	for {
+		resp, err := svc.ListTasks(params)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, resp.TaskArns...)
+		if resp.NextToken == nil {
+			break
+		}
+		params.NextToken = resp.NextToken
+	}
+	var ret []*ecs.Task
+	for i := 0; i < len(arns); i += 100 {
+		j := i + 100
+		if j > len(arns) {
+			j = len(arns)
+		}
+		resp, err := svc.DescribeTasks(&ecs.DescribeTasksInput{Cluster: cluster, Tasks: arns[i:j]})
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, resp.Tasks...)
+	}
+	return ret, nil
+}
+
+// checkTasks Returns a Failure if a task of the PRIMARY deployment of a service failed a health check,
+// or if maxStopped of its tasks stopped in a row. A running task which is not unhealthy resets the count
+// of the tasks created before it.
+func checkTasks(svc ecsiface.ECSAPI, cluster *string, s *ecs.Service, maxStopped int) error {
+	if maxStopped <= 0 {
+		return nil
+	}
+	d := Primary(s)
+	if d == nil {
+		return errors.New("The service " + aws.StringValue(s.ServiceName) + " has no PRIMARY deployment")
+	}
+	running, err := deploymentTasks(svc, cluster, d, "RUNNING")
+	if err != nil {
+		return err
+	}
+	var healthy time.Time
+	for _, t := range running {
+		if aws.StringValue(t.LastStatus) == "RUNNING" && aws.StringValue(t.HealthStatus) != "UNHEALTHY" && aws.TimeValue(t.CreatedAt).After(healthy) {
+			healthy = aws.TimeValue(t.CreatedAt)
+		}
+	}
+	stopped, err := deploymentTasks(svc, cluster, d, "STOPPED")
+	if err != nil {
+		return err
+	}
+	var failed []*ecs.Task
+	for _, t := range stopped {
+		reason := aws.StringValue(t.StoppedReason)
+		if strings.HasPrefix(reason, "Scaling activity") || !aws.TimeValue(t.CreatedAt).After(healthy) {
+			// Stopped by the scheduler when the desired count is lowered, or followed by a healthy task
+			continue
+		}
+		if strings.Contains(strings.ToLower(reason), "health check") {
+			return &Failure{Message: "The task " + *t.TaskArn + " of " + aws.StringValue(d.TaskDefinition) + " stopped: " + reason}
+		}
+		failed = append(failed, t)
+	}
+	if len(failed) >= maxStopped {
+		sort.Slice(failed, func(i, j int) bool {
+			return aws.TimeValue(failed[i].CreatedAt).Before(aws.TimeValue(failed[j].CreatedAt))
+		})
+		return &Failure{Message: fmt.Sprintf("%d tasks of %s stopped in a row, the last one because: %s", len(failed), aws.StringValue(d.TaskDefinition), aws.StringValue(failed[len(failed)-1].StoppedReason))}
+	}
+	return nil
+}
+
+// WaitStable Polls a service until it is stable, writing its events newer than the ones of the last known state.
+// It fails with a Failure if the service is not stable after the timeout or if its new tasks keep stopping.
+func WaitStable(svc ecsiface.ECSAPI, cluster *string, service *string, last *ecs.Service, w *Wait) (*ecs.Service, error) {
+	seen := map[string]bool{}
+	for _, e := range last.Events {
+		seen[*e.Id] = true
+	}
+	deadline := time.Now().Add(w.Timeout)
+	for {
+		resp, err := DescribeServices(svc, cluster, []*string{service})
+		if err != nil {
+			return last, err
+		}
+		last = resp.Services[0]
+		for i := len(last.Events) - 1; i >= 0; i-- {
+			e := last.Events[i]
+			if !seen[*e.Id] && w.Events != nil {
+				fmt.Fprintln(w.Events, aws.TimeValue(e.CreatedAt).Format(time.RFC3339)+" "+aws.StringValue(e.Message))
+			}
+			seen[*e.Id] = true
+		}
+		if Stable(last) {
+			return last, nil
+		}
+		d := Primary(last)
+		if d == nil {
+			// e.g. an INACTIVE service
+			return last, errors.New("The service " + aws.StringValue(last.ServiceName) + " has no PRIMARY deployment")
+		}
+		if err := checkTasks(svc, cluster, last, w.MaxStopped); err != nil {
+			return last, err
+		}
+		if !time.Now().Before(deadline) {
+			return last, &Failure{Message: fmt.Sprintf("Timeout (%s) reached: the service %s runs %d of %d tasks of %s in %d deployments",
+				w.Timeout, *last.ServiceName, aws.Int64Value(d.RunningCount), aws.Int64Value(d.DesiredCount),
+				aws.StringValue(d.TaskDefinition), len(last.Deployments))}
+		}
+		time.Sleep(w.Interval)
+	}
+}
+
+// DeployOutput The result of a deployment
+type DeployOutput struct {
+	Service *ecs.Service
+	// Previous The task definition of the service before the deployment
+	Previous string
+	// Failure The cause of the failure of the deployment, if it failed
+	Failure string
+	// RolledBack Whether the service was rolled back to the previous task definition and is stable
+	RolledBack bool
+	// RollbackFailure The cause of the failure of the rollback, if it failed
+	RollbackFailure string
+}
+
+// Deploy Updates the task definition of a service and waits until the deployment is stable.
+// If the deployment fails and rollback is set, the service is updated back to its previous task definition
+// and the rollback is waited for the same way. The error reports the failure and the result of the rollback.
+func Deploy(svc ecsiface.ECSAPI, cluster *string, service *string, taskDef *string, w *Wait, rollback bool) (*DeployOutput, error) {
+	desc, err := DescribeServices(svc, cluster, []*string{service})
+	if err != nil {
+		return nil, err
+	}
+	out := &DeployOutput{Previous: aws.StringValue(desc.Services[0].TaskDefinition)}
+	resp, err := UpdateService(svc, &ecs.UpdateServiceInput{Cluster: cluster, Service: service, TaskDefinition: taskDef})
+	if err != nil {
+		return nil, err
+	}
+	out.Service, err = WaitStable(svc, cluster, service, resp.Service, w)
+	failure, ok := err.(*Failure)
+	if !ok {
+		return out, err
+	}
+	out.Failure = failure.Message
+	msg := "The deployment of " + aws.StringValue(resp.Service.TaskDefinition) + " failed: " + failure.Message
+	if !rollback || out.Previous == aws.StringValue(resp.Service.TaskDefinition) {
+		return out, errors.New(msg)
+	}
+
+	if w.Events != nil {
+		fmt.Fprintln(w.Events, "Rolling back to "+out.Previous)
+	}
+	resp, err = UpdateService(svc, &ecs.UpdateServiceInput{Cluster: cluster, Service: service, TaskDefinition: &out.Previous})
+	if err == nil {
+		out.Service, err = WaitStable(svc, cluster, service, resp.Service, w)
+	}
+	if err != nil {
+		out.RollbackFailure = err.Error()
+		return out, errors.New(msg + "\nThe rollback to " + out.Previous + " failed: " + err.Error())
+	}
+	out.RolledBack = true
+	return out, errors.New(msg + "\nRolled back to " + out.Previous)
+}
+
+// WaitOptions The parameters of the commands waiting for a deployment
+type WaitOptions struct {
+	Timeout    int64
+	Interval   int64
+	MaxStopped int
+	Rollback   bool
+}
+
+// Flags Adds the parameters to a flag set
+func (o *WaitOptions) Flags(c *flag.FlagSet) {
+	c.Int64Var(&o.Timeout, "timeout", 600, "The maximum number of seconds to wait for the deployment to be stable.")
+	c.Int64Var(&o.Interval, "interval", 5, "The number of seconds between two polls of the service.")
+	c.IntVar(&o.MaxStopped, "max-stopped", 3, "The deployment fails when this number of new tasks stopped in a row, without a new task running in between, or when a new task fails a health check. Set it to 0 to wait until the timeout.")
+	c.BoolVar(&o.Rollback, "rollback", true, "Deploy the previous task definition of the service again if the deployment fails.")
+}
+
+// Wait Returns how to wait for the deployment, writing the events of the service to stderr
+func (o *WaitOptions) Wait() *Wait {
+	return &Wait{
+		Interval:   time.Duration(o.Interval) * time.Second,
+		Timeout:    time.Duration(o.Timeout) * time.Second,
+		Events:     os.Stderr,
+		MaxStopped: o.MaxStopped,
+	}
+}
+
+// deployOptions The parameters of the deploy command
+type deployOptions struct {
+	WaitOptions
+	cluster string
+	service string
+	taskDef string
+}
+
+func (o *deployOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	clusterFlag(c, &o.cluster)
+	serviceFlag(c, &o.service)
+	c.StringVar(&o.taskDef, "task-definition", "", "The family for the latest ACTIVE revision, family and revision (family:revision) or full Amazon Resource Name (ARN) of the task definition to deploy.")
+	o.WaitOptions.Flags(c)
+	return c
+}
+
+func cliDeployParams(args []string) *flag.FlagSet {
+	return new(deployOptions).flags(args)
+}
+
+func cliDeploy(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o deployOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if o.service == "" || o.taskDef == "" {
+		return nil, errors.New("The service and task-definition parameters are required")
+	}
+	out, err := Deploy(c.ECS, &o.cluster, &o.service, &o.taskDef, o.Wait(), o.Rollback)
+	if out == nil || out.Service == nil {
+		return nil, err
+	}
+	return cli.NewResult(out, serviceLines([]*ecs.Service{out.Service})...), err
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/fake"
+)
+
+// register Registers a revision of the web family running the image
+func register(t *testing.T, b *fake.Backend, image string, memory int64) {
+	_, err := b.RegisterTaskDefinition(&ecs.RegisterTaskDefinitionInput{
+		Family:               aws.String("web"),
+		ContainerDefinitions: []*ecs.ContainerDefinition{{Name: aws.String("app"), Image: aws.String(image), Memory: aws.Int64(memory)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeploy(t *testing.T) {
+	b := newBackend(t)
+	_, err := CreateService(b, &ecs.CreateServiceInput{ServiceName: aws.String("web"), TaskDefinition: aws.String("web:1"), DesiredCount: aws.Int64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events bytes.Buffer
+	w := &Wait{Timeout: time.Minute, Events: &events}
+	out, err := Deploy(b, nil, aws.String("web"), aws.String("web:2"), w, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := out.Service; !Stable(s) || !strings.HasSuffix(*s.Deployments[0].TaskDefinition, "web:2") {
+		t.Errorf("deployed service = %v", s)
+	}
+	if !strings.HasSuffix(out.Previous, "web:1") {
+		t.Errorf("previous task definition = %s, want web:1", out.Previous)
+	}
+	lines := strings.Split(strings.TrimSpace(events.String()), "\n")
+	if last := lines[len(lines)-1]; !strings.HasSuffix(last, "(service web) has reached a steady state.") {
+		t.Errorf("last event = %q", last)
+	}
+	if !strings.Contains(events.String(), "has stopped 2 running tasks") {
+		t.Errorf("events = %q, want the stop of the old tasks", events.String())
+	}
+
+	// The tasks of revision 3 can not be placed
+	register(t, b, "example/web", 8192)
+	events.Reset()
+	w.Timeout = 0
+	out, err = Deploy(b, nil, aws.String("web"), aws.String("web:3"), w, false)
+	if err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("deploy of a task which can not be placed error = %v", err)
+	}
+	if out == nil || len(out.Service.Deployments) != 2 || out.RolledBack {
+		t.Errorf("deploy output after timeout = %+v", out)
+	}
+	if !strings.Contains(events.String(), "was unable to place a task") {
+		t.Errorf("events = %q, want a placement failure", events.String())
+	}
+}
+
+func TestDeployRollback(t *testing.T) {
+	state := fake.DefaultState()
+	state.Failing = map[string]string{
+		"example/web:crash":     "Essential container in task exited",
+		"example/web:unhealthy": "Task failed container health checks",
+	}
+	b := fake.New(state, "us-east-1")
+	register(t, b, "example/web:1", 128)
+	register(t, b, "example/web:crash", 128)
+	register(t, b, "example/web:unhealthy", 128)
+	_, err := CreateService(b, &ecs.CreateServiceInput{ServiceName: aws.String("web"), TaskDefinition: aws.String("web:1"), DesiredCount: aws.Int64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &Wait{Timeout: time.Minute, MaxStopped: 3}
+	if _, err := WaitStable(b, nil, aws.String("web"), &ecs.Service{}, w); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		taskDef string
+		failure string
+	}{
+		{"web:2", "tasks of " + "arn:aws:ecs:us-east-1:" + fake.Account + ":task-definition/web:2 stopped in a row, the last one because: Essential container in task exited"},
+		{"web:3", "stopped: Task failed container health checks"},
+	} {
+		out, err := Deploy(b, nil, aws.String("web"), aws.String(tc.taskDef), w, true)
+		if err == nil || !strings.Contains(err.Error(), "Rolled back to") {
+			t.Errorf("deploy of %s error = %v, want a rollback", tc.taskDef, err)
+		}
+		if out == nil {
+			continue
+		}
+		if !strings.Contains(out.Failure, tc.failure) {
+			t.Errorf("deploy of %s failure = %q, want %q", tc.taskDef, out.Failure, tc.failure)
+		}
+		if !out.RolledBack || !Stable(out.Service) || *out.Service.TaskDefinition != out.Previous || !strings.HasSuffix(out.Previous, "web:1") {
+			t.Errorf("deploy of %s output = %+v, want a stable rollback to web:1", tc.taskDef, out)
+		}
+	}
+}
+
+// tasksAPI Serves the tasks of a deployment to checkTasks
+type tasksAPI struct {
+	ecsiface.ECSAPI
+	tasks []*ecs.Task
+}
+
+func (a *tasksAPI) ListTasks(in *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
+	out := &ecs.ListTasksOutput{}
+	for _, t := range a.tasks {
+		if *t.StartedBy == *in.StartedBy && *t.DesiredStatus == *in.DesiredStatus {
+			out.TaskArns = append(out.TaskArns, t.TaskArn)
+		}
+	}
+	return out, nil
+}
+
+func (a *tasksAPI) DescribeTasks(in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	out := &ecs.DescribeTasksOutput{}
+	for _, t := range a.tasks {
+		for _, arn := range in.Tasks {
+			if *t.TaskArn == *arn {
+				out.Tasks = append(out.Tasks, t)
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestCheckTasks(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := func(i int, deployment string, status string, reason string) *ecs.Task {
+		desired := "RUNNING"
+		if status == "STOPPED" {
+			desired = "STOPPED"
+		}
+		return &ecs.Task{
+			TaskArn:       aws.String(fmt.Sprintf("task/%d", i)),
+			StartedBy:     aws.String(deployment),
+			LastStatus:    aws.String(status),
+			DesiredStatus: aws.String(desired),
+			StoppedReason: aws.String(reason),
+			CreatedAt:     aws.Time(start.Add(time.Duration(i) * time.Second)),
+		}
+	}
+	// The PRIMARY deployment is not the first one
+	s := &ecs.Service{ServiceName: aws.String("web"), Deployments: []*ecs.Deployment{
+		{Id: aws.String("ecs-svc/1"), Status: aws.String("ACTIVE"), TaskDefinition: aws.String("web:1")},
+		{Id: aws.String("ecs-svc/2"), Status: aws.String("PRIMARY"), TaskDefinition: aws.String("web:2")},
+	}}
+	exited := "Essential container in task exited"
+	api := &tasksAPI{tasks: []*ecs.Task{
+		task(1, "ecs-svc/2", "STOPPED", exited),
+		task(2, "ecs-svc/2", "STOPPED", exited),
+		task(3, "ecs-svc/2", "RUNNING", ""),
+		task(4, "ecs-svc/2", "STOPPED", exited),
+		task(5, "ecs-svc/2", "STOPPED", exited),
+		task(6, "ecs-svc/1", "STOPPED", exited),
+		task(7, "ecs-svc/2", "STOPPED", "Scaling activity initiated by (deployment ecs-svc/2)"),
+	}}
+	if err := checkTasks(api, nil, s, 3); err != nil {
+		t.Errorf("checkTasks with 2 tasks stopped since the last running one = %v", err)
+	}
+	api.tasks = append(api.tasks, task(8, "ecs-svc/2", "STOPPED", exited))
+	if err, ok := checkTasks(api, nil, s, 3).(*Failure); !ok || !strings.HasPrefix(err.Message, "3 tasks of web:2 stopped in a row") {
+		t.Errorf("checkTasks with 3 tasks stopped in a row = %v", err)
+	}
+
+	if err := checkTasks(api, nil, &ecs.Service{ServiceName: aws.String("web")}, 3); err == nil {
+		t.Error("checkTasks of a service without deployment succeeded")
+	}
+	if Stable(&ecs.Service{ServiceName: aws.String("web")}) {
+		t.Error("a service without deployment is stable")
+	}
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+// clusterFlag Defines the cluster parameter shared by the commands
+func clusterFlag(c *flag.FlagSet, p *string) {
+	c.StringVar(p, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the service. If you do not specify a cluster, the default cluster is assumed.")
+}
+
+// serviceFlag Defines the service parameter shared by the commands acting on a single service
+func serviceFlag(c *flag.FlagSet, p *string) {
+	c.StringVar(p, "service", "", "The name or full Amazon Resource Name (ARN) of the service.")
+}
+
+// ParseLoadBalancer Parses a load balancer of a service in the form
+// 'load-balancer=web-elb,container=web-app,port=9000' for a classic load balancer,
+// or 'target-group=arn:aws:elasticloadbalancing:...,container=web-app,port=9000' for a target group.
+func ParseLoadBalancer(spec string) (*ecs.LoadBalancer, error) {
+	lb := &ecs.LoadBalancer{}
+	for _, field := range strings.Split(spec, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.New("Invalid load balancer " + spec + ": " + field + " is not in the key=value format")
+		}
+		switch kv[0] {
+		case "load-balancer":
+			lb.LoadBalancerName = aws.String(kv[1])
+		case "target-group":
+			lb.TargetGroupArn = aws.String(kv[1])
+		case "container":
+			lb.ContainerName = aws.String(kv[1])
+		case "port":
+			port, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return nil, errors.New("Invalid load balancer " + spec + ": invalid port " + kv[1])
+			}
+			lb.ContainerPort = aws.Int64(port)
+		default:
+			return nil, errors.New("Invalid load balancer " + spec + ": unknown field " + kv[0])
+		}
+	}
+	if (lb.LoadBalancerName == nil) == (lb.TargetGroupArn == nil) {
+		return nil, errors.New("Invalid load balancer " + spec + ": either load-balancer or target-group is required")
+	}
+	if lb.ContainerName == nil || lb.ContainerPort == nil {
+		return nil, errors.New("Invalid load balancer " + spec + ": container and port are required")
+	}
+	return lb, nil
+}
+
+// deploymentConfiguration Returns the deployment configuration of the percents, which are left out if negative
+func deploymentConfiguration(maxPercent int64, minHealthyPercent int64) *ecs.DeploymentConfiguration {
+	if maxPercent < 0 && minHealthyPercent < 0 {
+		return nil
+	}
+	config := &ecs.DeploymentConfiguration{}
+	if maxPercent >= 0 {
+		config.MaximumPercent = aws.Int64(maxPercent)
+	}
+	if minHealthyPercent >= 0 {
+		config.MinimumHealthyPercent = aws.Int64(minHealthyPercent)
+	}
+	return config
+}
+
+// CreateService Creates a service running and maintaining the desired count of tasks of a task definition
+func CreateService(svc ecsiface.ECSAPI, params *ecs.CreateServiceInput) (*ecs.CreateServiceOutput, error) {
+	return svc.CreateService(params)
+}
+
+// createServiceOptions The parameters of the create command
+type createServiceOptions struct {
+	cluster           string
+	service           string
+	taskDef           string
+	desiredCount      int64
+	loadBalancers     cli.Strings
+	role              string
+	maxPercent        int64
+	minHealthyPercent int64
+}
+
+func (o *createServiceOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	clusterFlag(c, &o.cluster)
+	c.StringVar(&o.service, "service", "", "The name of the service. Up to 255 letters (uppercase and lowercase), numbers, hyphens, and underscores are allowed.")
+	c.StringVar(&o.taskDef, "task-definition", "", "The family for the latest ACTIVE revision, family and revision (family:revision) or full Amazon Resource Name (ARN) of the task definition to run.")
+	c.Int64Var(&o.desiredCount, "desired-count", 1, "The number of instantiations of the task definition to place and keep running.")
+	c.Var(&o.loadBalancers, "load-balancer", "A load balancer of the service in the form 'load-balancer=name,container=name,port=9000' or 'target-group=arn,container=name,port=9000'. Can be repeated.")
+	c.StringVar(&o.role, "role", "", "The name or ARN of the IAM role that allows Amazon ECS to make calls to the load balancers. Required with a load balancer, unless the service-linked role is used.")
+	c.Int64Var(&o.maxPercent, "max-percent", 200, "The upper limit of the running or pending tasks during a deployment, as a percentage of the desired count.")
+	c.Int64Var(&o.minHealthyPercent, "min-healthy-percent", 100, "The lower limit of the running tasks during a deployment, as a percentage of the desired count.")
+	return c
+}
+
+func cliCreateServiceParams(args []string) *flag.FlagSet {
+	return new(createServiceOptions).flags(args)
+}
+
+func cliCreateService(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o createServiceOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if o.service == "" || o.taskDef == "" {
+		return nil, errors.New("The service and task-definition parameters are required")
+	}
+	params := &ecs.CreateServiceInput{
+		Cluster:                 &o.cluster,
+		ServiceName:             &o.service,
+		TaskDefinition:          &o.taskDef,
+		DesiredCount:            &o.desiredCount,
+		Role:                    cli.String(o.role),
+		DeploymentConfiguration: deploymentConfiguration(o.maxPercent, o.minHealthyPercent),
+	}
+	for _, spec := range o.loadBalancers {
+		lb, err := ParseLoadBalancer(spec)
+		if err != nil {
+			return nil, err
+		}
+		params.LoadBalancers = append(params.LoadBalancers, lb)
+	}
+	resp, err := CreateService(c.ECS, params)
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, *resp.Service.ServiceArn), nil
+}
+
+// UpdateService Changes the desired count, the task definition or the deployment configuration of a service
+func UpdateService(svc ecsiface.ECSAPI, params *ecs.UpdateServiceInput) (*ecs.UpdateServiceOutput, error) {
+	return svc.UpdateService(params)
+}
+
+// updateServiceOptions The parameters of the update command
+type updateServiceOptions struct {
+	cluster           string
+	service           string
+	taskDef           string
+	desiredCount      int64
+	maxPercent        int64
+	minHealthyPercent int64
+}
+
+func (o *updateServiceOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	clusterFlag(c, &o.cluster)
+	serviceFlag(c, &o.service)
+	c.StringVar(&o.taskDef, "task-definition", "", "The family for the latest ACTIVE revision, family and revision (family:revision) or full Amazon Resource Name (ARN) of the task definition to run. A new task definition starts a deployment. Unchanged by default.")
+	c.Int64Var(&o.desiredCount, "desired-count", -1, "The number of instantiations of the task definition to place and keep running. Unchanged by default.")
+	c.Int64Var(&o.maxPercent, "max-percent", -1, "The upper limit of the running or pending tasks during a deployment, as a percentage of the desired count. Unchanged by default.")
+	c.Int64Var(&o.minHealthyPercent, "min-healthy-percent", -1, "The lower limit of the running tasks during a deployment, as a percentage of the desired count. Unchanged by default.")
+	return c
+}
+
+func cliUpdateServiceParams(args []string) *flag.FlagSet {
+	return new(updateServiceOptions).flags(args)
+}
+
+func cliUpdateService(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o updateServiceOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if o.service == "" {
+		return nil, errors.New("The service parameter is required")
+	}
+	params := &ecs.UpdateServiceInput{
+		Cluster:                 &o.cluster,
+		Service:                 &o.service,
+		TaskDefinition:          cli.String(o.taskDef),
+		DeploymentConfiguration: deploymentConfiguration(o.maxPercent, o.minHealthyPercent),
+	}
+	if o.desiredCount >= 0 {
+		params.DesiredCount = &o.desiredCount
+	}
+	resp, err := UpdateService(c.ECS, params)
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, *resp.Service.ServiceArn), nil
+}
+
+// DeleteService Scales a service to 0, then deletes it. Its tasks are stopped by ECS.
+func DeleteService(svc ecsiface.ECSAPI, cluster *string, service *string) (*ecs.DeleteServiceOutput, error) {
+	_, err := svc.UpdateService(&ecs.UpdateServiceInput{
+		Cluster:      cluster,
+		Service:      service,
+		DesiredCount: aws.Int64(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc.DeleteService(&ecs.DeleteServiceInput{Cluster: cluster, Service: service})
+}
+
+// serviceNameOptions The parameters of the commands acting on a single service
+type serviceNameOptions struct {
+	cluster string
+	service string
+}
+
+func (o *serviceNameOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	clusterFlag(c, &o.cluster)
+	serviceFlag(c, &o.service)
+	return c
+}
+
+func cliServiceNameParams(args []string) *flag.FlagSet {
+	return new(serviceNameOptions).flags(args)
+}
+
+func cliDeleteService(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o serviceNameOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if o.service == "" {
+		return nil, errors.New("The service parameter is required")
+	}
+	resp, err := DeleteService(c.ECS, &o.cluster, &o.service)
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, *resp.Service.ServiceArn), nil
+}
+
+// ListServices Returns the ARNs of the services of a cluster
+func ListServices(svc ecsiface.ECSAPI, cluster *string, maxResults *int64) (*ecs.ListServicesOutput, error) {
+	return svc.ListServices(&ecs.ListServicesInput{Cluster: cluster, MaxResults: maxResults})
+}
+
+// listServicesOptions The parameters of the list command
+type listServicesOptions struct {
+	cluster    string
+	maxResults int64
+}
+
+func (o *listServicesOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	clusterFlag(c, &o.cluster)
+	c.Int64Var(&o.maxResults, "max-items", 100, "The maximum number of service results returned by ListServices in paginated output.")
+	return c
+}
+
+func cliListServicesParams(args []string) *flag.FlagSet {
+	return new(listServicesOptions).flags(args)
+}
+
+func cliListServices(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o listServicesOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	resp, err := ListServices(c.ECS, &o.cluster, &o.maxResults)
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, aws.StringValueSlice(resp.ServiceArns)...), nil
+}
+
+// DescribeServices Describes services of a cluster
+func DescribeServices(svc ecsiface.ECSAPI, cluster *string, services []*string) (*ecs.DescribeServicesOutput, error) {
+	resp, err := svc.DescribeServices(&ecs.DescribeServicesInput{Cluster: cluster, Services: services})
+	if err != nil {
+		return nil, err
+	}
+	return resp, cli.Failure(resp.Failures, nil)
+}
+
+// serviceLines Returns a line per service with its counts and a line per deployment
+func serviceLines(services []*ecs.Service) []string {
+	var ret []string
+	for _, s := range services {
+		ret = append(ret, fmt.Sprintf("%s %s %s running %d/%d pending %d",
+			aws.StringValue(s.ServiceName), aws.StringValue(s.Status), aws.StringValue(s.TaskDefinition),
+			aws.Int64Value(s.RunningCount), aws.Int64Value(s.DesiredCount), aws.Int64Value(s.PendingCount)))
+		for _, d := range s.Deployments {
+			ret = append(ret, fmt.Sprintf("  %s %s running %d/%d pending %d",
+				aws.StringValue(d.Status), aws.StringValue(d.TaskDefinition),
+				aws.Int64Value(d.RunningCount), aws.Int64Value(d.DesiredCount), aws.Int64Value(d.PendingCount)))
+		}
+	}
+	return ret
+}
+
+// describeServicesOptions The parameters of the describe command
+type describeServicesOptions struct {
+	cluster  string
+	services cli.Strings
+}
+
+func (o *describeServicesOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	clusterFlag(c, &o.cluster)
+	c.Var(&o.services, "service", "The name or full Amazon Resource Name (ARN) of a service to describe. Can be repeated.")
+	return c
+}
+
+func cliDescribeServicesParams(args []string) *flag.FlagSet {
+	return new(describeServicesOptions).flags(args)
+}
+
+func cliDescribeServices(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o describeServicesOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if len(o.services) == 0 {
+		return nil, errors.New("The service parameter is required")
+	}
+	resp, err := DescribeServices(c.ECS, &o.cluster, aws.StringSlice(o.services))
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, serviceLines(resp.Services)...), nil
+}
+
+// The columns of the table output of services
+var serviceColumns = []cli.Column{
+	{Header: "NAME", Path: "ServiceName"},
+	{Header: "STATUS", Path: "Status"},
+	{Header: "TASK DEFINITION", Path: "TaskDefinition"},
+	{Header: "DESIRED", Path: "DesiredCount"},
+	{Header: "RUNNING", Path: "RunningCount"},
+	{Header: "PENDING", Path: "PendingCount"},
+	{Header: "DEPLOYMENTS", Path: "length(Deployments)"},
+}
+
+// The table output of a single service
+var serviceTable = &cli.Table{Rows: "Service", Columns: serviceColumns}
+
+var commands = map[string]cli.Command{
+	"create": {
+		Cmd:   cliCreateService,
+		Desc:  "Runs and maintains a desired number of tasks from a specified task definition, optionally behind load balancers.",
+		Help:  cliCreateServiceParams,
+		Table: serviceTable,
+	},
+	"update": {
+		Cmd:   cliUpdateService,
+		Desc:  "Modifies the desired count, deployment configuration, or task definition used in a service. A new task definition starts a deployment.",
+		Help:  cliUpdateServiceParams,
+		Table: serviceTable,
+	},
+	"delete": {
+		Cmd:   cliDeleteService,
+		Desc:  "Scales a service to 0, then deletes it. Its tasks are stopped by ECS.",
+		Help:  cliServiceNameParams,
+		Table: serviceTable,
+	},
+	"deploy": {
+		Cmd:   cliDeploy,
+		Desc:  "Deploys a task definition to a service and waits until the new tasks run and the old ones are stopped, printing the events of the service. It fails if the deployment is not stable after the timeout or if the new tasks keep stopping, and then deploys the previous task definition again.",
+		Help:  cliDeployParams,
+		Table: &cli.Table{Rows: "Service", Columns: serviceColumns},
+	},
+	"list": {
+		Cmd:  cliListServices,
+		Desc: "Lists the services that are running in a specified cluster.",
+		Help: cliListServicesParams,
+		Table: &cli.Table{
+			Rows:    "ServiceArns",
+			Columns: []cli.Column{{Header: "SERVICE ARN"}},
+		},
+	},
+	"describe": {
+		Cmd:   cliDescribeServices,
+		Desc:  "Describes the specified services running in your cluster, with their deployments.",
+		Help:  cliDescribeServicesParams,
+		Table: &cli.Table{Rows: "Services", Columns: serviceColumns},
+	},
+}
+
+// Run Main entry point, which runs a command or display a help message.
+func Run(c *cli.Clients, o *cli.Options, command string, args []string) error {
+	return cli.Run(c, o, command, commands, args)
+}
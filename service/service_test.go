@@ -0,0 +1,102 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/fake"
+)
+
+const serviceArn = "arn:aws:ecs:us-east-1:" + fake.Account + ":service/default/web"
+
+// newBackend Returns a fake backend with two revisions of the web task definition
+func newBackend(t *testing.T) *fake.Backend {
+	b := fake.New(fake.DefaultState(), "us-east-1")
+	for i := 0; i < 2; i++ {
+		_, err := b.RegisterTaskDefinition(&ecs.RegisterTaskDefinitionInput{
+			Family:               aws.String("web"),
+			ContainerDefinitions: []*ecs.ContainerDefinition{{Name: aws.String("app"), Image: aws.String("example/web"), Memory: aws.Int64(128)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+func TestParseLoadBalancer(t *testing.T) {
+	lb, err := ParseLoadBalancer("target-group=arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/73e2d6bc24d8a067,container=app,port=9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ecs.LoadBalancer{
+		TargetGroupArn: aws.String("arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/web/73e2d6bc24d8a067"),
+		ContainerName:  aws.String("app"),
+		ContainerPort:  aws.Int64(9000),
+	}
+	if !reflect.DeepEqual(lb, want) {
+		t.Errorf("ParseLoadBalancer = %v, want %v", lb, want)
+	}
+	for _, spec := range []string{
+		"load-balancer=web-elb,container=app",
+		"load-balancer=web-elb,target-group=arn,container=app,port=9000",
+		"load-balancer=web-elb,container=app,port=http",
+		"load-balancer=web-elb,container=app,port=9000,protocol=tcp",
+	} {
+		if _, err := ParseLoadBalancer(spec); err == nil {
+			t.Errorf("ParseLoadBalancer(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestServiceCommands(t *testing.T) {
+	c := newBackend(t).Clients()
+	ret, err := commands["create"].Cmd(c, []string{"-service", "web", "-task-definition", "web:1", "-desired-count", "2", "-load-balancer", "load-balancer=web-elb,container=app,port=9000", "-role", "ecsServiceRole"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ret.Value.(*ecs.CreateServiceOutput).Service; *s.ServiceArn != serviceArn || *s.LoadBalancers[0].LoadBalancerName != "web-elb" {
+		t.Errorf("created service = %v", s)
+	}
+
+	ret, err = commands["update"].Cmd(c, []string{"-service", "web", "-task-definition", "web:2", "-min-healthy-percent", "50"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := ret.Value.(*ecs.UpdateServiceOutput).Service
+	if *s.DesiredCount != 2 || *s.DeploymentConfiguration.MinimumHealthyPercent != 50 || *s.DeploymentConfiguration.MaximumPercent != 200 {
+		t.Errorf("updated service = %v", s)
+	}
+	if len(s.Deployments) != 2 || *s.Deployments[0].Status != "PRIMARY" {
+		t.Errorf("deployments after update = %v", s.Deployments)
+	}
+
+	ret, err = commands["list"].Cmd(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ret.Lines, []string{serviceArn}) {
+		t.Errorf("list = %v", ret.Lines)
+	}
+
+	ret, err = commands["describe"].Cmd(c, []string{"-service", "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := ret.Lines[0]; line[:len("web ACTIVE")] != "web ACTIVE" {
+		t.Errorf("describe = %q", ret.Lines)
+	}
+	if _, err := commands["describe"].Cmd(c, []string{"-service", "web", "-service", "api"}); err == nil {
+		t.Error("describe of a missing service succeeded")
+	}
+
+	ret, err = commands["delete"].Cmd(c, []string{"-service", "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ret.Value.(*ecs.DeleteServiceOutput).Service; *s.Status != "DRAINING" || *s.DesiredCount != 0 {
+		t.Errorf("deleted service = %v", s)
+	}
+}
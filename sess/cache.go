@@ -0,0 +1,105 @@
+package sess
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// The credentials are refreshed this long before they actually expire
+const expiryWindow = time.Minute
+
+// expiringProvider A credentials provider which knows when its credentials expire
+type expiringProvider interface {
+	credentials.Provider
+	ExpiresAt() time.Time
+}
+
+// cachedCredentials The on disk format of the cached credentials
+type cachedCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// cachedProvider Keeps the temporary credentials of an other provider on disk until they expire,
+// so the MFA token is not asked for on every invocation
+type cachedProvider struct {
+	path       string
+	provider   expiringProvider
+	expiration time.Time
+}
+
+// newCachedProvider Returns a provider caching the credentials of p in a file named after key
+func newCachedProvider(p expiringProvider, key string) *cachedProvider {
+	sum := sha1.Sum([]byte(key))
+	var path string
+	if home, err := os.UserHomeDir(); err == nil {
+		path = filepath.Join(home, ".ecs", "cache", hex.EncodeToString(sum[:])+".json")
+	}
+	return &cachedProvider{path: path, provider: p}
+}
+
+// Retrieve Returns the cached credentials, or retrieves and caches new ones if they are missing or expired
+func (p *cachedProvider) Retrieve() (credentials.Value, error) {
+	if c, err := p.load(); err == nil && time.Now().Add(expiryWindow).Before(c.Expiration) {
+		p.expiration = c.Expiration
+		return credentials.Value{
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			SessionToken:    c.SessionToken,
+			ProviderName:    "CachedAssumeRoleProvider",
+		}, nil
+	}
+	v, err := p.provider.Retrieve()
+	if err != nil {
+		return v, err
+	}
+	p.expiration = p.provider.ExpiresAt()
+	// The cache is an optimization only, failing to write it is not an error
+	p.save(&cachedCredentials{
+		AccessKeyID:     v.AccessKeyID,
+		SecretAccessKey: v.SecretAccessKey,
+		SessionToken:    v.SessionToken,
+		Expiration:      p.expiration,
+	})
+	return v, nil
+}
+
+// IsExpired Returns true if the credentials must be retrieved again
+func (p *cachedProvider) IsExpired() bool {
+	return time.Now().Add(expiryWindow).After(p.expiration)
+}
+
+func (p *cachedProvider) load() (*cachedCredentials, error) {
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	var c cachedCredentials
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (p *cachedProvider) save(c *cachedCredentials) error {
+	if p.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p.path, data, 0600)
+}
@@ -0,0 +1,167 @@
+package sess
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// staticProvider Returns numbered credentials expiring after a given time
+type staticProvider struct {
+	calls   int
+	expires time.Duration
+}
+
+func (p *staticProvider) Retrieve() (credentials.Value, error) {
+	p.calls++
+	return credentials.Value{AccessKeyID: fmt.Sprintf("AKID%d", p.calls), SecretAccessKey: "secret", SessionToken: "token"}, nil
+}
+
+func (p *staticProvider) IsExpired() bool {
+	return true
+}
+
+func (p *staticProvider) ExpiresAt() time.Time {
+	return time.Now().Add(p.expires)
+}
+
+// tempHome Sets HOME to a new temporary directory, and returns a function restoring it
+func tempHome(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", dir)
+	return func() {
+		os.Setenv("HOME", home)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCachedProvider(t *testing.T) {
+	defer tempHome(t)()
+	p := &staticProvider{expires: time.Hour}
+	v, err := newCachedProvider(p, "profile|role").Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.AccessKeyID != "AKID1" || p.calls != 1 {
+		t.Errorf("first Retrieve = %v after %d calls", v, p.calls)
+	}
+	cached := newCachedProvider(p, "profile|role")
+	fi, err := os.Stat(cached.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("cache file mode = %v, want 0600", fi.Mode().Perm())
+	}
+
+	// A fresh entry is reused
+	v, err = cached.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.AccessKeyID != "AKID1" || v.ProviderName != "CachedAssumeRoleProvider" || p.calls != 1 || cached.IsExpired() {
+		t.Errorf("Retrieve of a fresh entry = %v after %d calls", v, p.calls)
+	}
+
+	// An other key has its own entry
+	if v, _ := newCachedProvider(p, "profile|other-role").Retrieve(); v.AccessKeyID != "AKID2" {
+		t.Errorf("Retrieve of an other key = %v", v)
+	}
+
+	// An entry expiring within the expiry window is refreshed
+	expiring := newCachedProvider(p, "profile|role")
+	if err := expiring.save(&cachedCredentials{AccessKeyID: "OLD", Expiration: time.Now().Add(expiryWindow / 2)}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := expiring.Retrieve(); v.AccessKeyID != "AKID3" {
+		t.Errorf("Retrieve of an expired entry = %v", v)
+	}
+	if c, err := expiring.load(); err != nil || c.AccessKeyID != "AKID3" {
+		t.Errorf("cached credentials after the refresh = %v, %v", c, err)
+	}
+
+	// A corrupt file is ignored and replaced
+	if err := ioutil.WriteFile(expiring.path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := expiring.Retrieve(); err != nil || v.AccessKeyID != "AKID4" {
+		t.Errorf("Retrieve with a corrupt cache = %v, %v", v, err)
+	}
+	if c, err := expiring.load(); err != nil || c.AccessKeyID != "AKID4" {
+		t.Errorf("cached credentials after a corrupt cache = %v, %v", c, err)
+	}
+}
+
+func TestAssumeRole(t *testing.T) {
+	defer tempHome(t)()
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDPROFILE")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.Form.Encode())
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAROLE</AccessKeyId>
+      <SecretAccessKey>role-secret</SecretAccessKey>
+      <SessionToken>role-token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	config := &Config{Region: "us-east-1", Endpoint: server.URL, RoleArn: "arn:aws:iam::123456789012:role/deploy", ExternalID: "ext"}
+	for i := 0; i < 2; i++ {
+		s, err := New(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := s.Config.Credentials.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.AccessKeyID != "ASIAROLE" || v.SessionToken != "role-token" {
+			t.Errorf("%d. assumed role credentials = %v", i, v)
+		}
+	}
+	// The second session uses the cached credentials
+	if len(requests) != 1 || !strings.Contains(requests[0], "ExternalId=ext") || !strings.Contains(requests[0], "RoleArn=arn") {
+		t.Errorf("AssumeRole requests = %q", requests)
+	}
+
+	// The MFA token is read from the terminal only
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	config.MFASerial = "arn:aws:iam::123456789012:mfa/user"
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Config.Credentials.Get(); err == nil || !strings.Contains(err.Error(), "standard input is not a terminal") {
+		t.Errorf("assume role with MFA without terminal error = %v", err)
+	}
+}
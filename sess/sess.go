@@ -1,18 +1,114 @@
 package sess
 
 import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
-var az = "us-east-1"
+// DefaultRegion The region used when neither the flags, the environment nor the profile sets one
+const DefaultRegion = "us-east-1"
+
+// Config The settings of an AWS session
+type Config struct {
+	// Region The AWS region of the clients
+	Region string
+	// Profile The shared credentials profile. The default credential chain is used if it is empty.
+	Profile string
+	// Endpoint An optional endpoint URL, which overrides the default service endpoints
+	Endpoint string
+	// Endpoints Endpoint URLs by service endpoint ID (ecs, ec2, sts...), which override Endpoint for the given services
+	Endpoints map[string]string
+	// Insecure Disables the verification of the TLS certificates of the endpoints
+	Insecure bool
+	// RoleArn The ARN of an IAM role to assume. The credentials of the profile are used to assume it.
+	RoleArn string
+	// ExternalID The external ID required by the trust policy of the role
+	ExternalID string
+	// MFASerial The serial number or ARN of the MFA device required by the trust policy of the role
+	MFASerial string
+}
 
-var sess *session.Session
+// New Returns a new AWS session for the given region, profile and endpoint
+func New(c *Config) (*session.Session, error) {
+	cfg := aws.Config{}
+	if c.Region != "" {
+		cfg.Region = aws.String(c.Region)
+	}
+	if c.Endpoint != "" || len(c.Endpoints) > 0 {
+		cfg.EndpointResolver = c.resolver()
+	}
+	if c.Insecure {
+		cfg.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+	}
+	s, err := session.NewSessionWithOptions(session.Options{
+		Config:            cfg,
+		Profile:           c.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if aws.StringValue(s.Config.Region) == "" {
+		s.Config.Region = aws.String(DefaultRegion)
+	}
+	if c.RoleArn != "" {
+		s.Config.Credentials = assumeRole(s, c)
+	}
+	return s, nil
+}
+
+// resolver Returns an endpoint resolver applying the endpoint overrides of every client created from the session
+func (c *Config) resolver() endpoints.Resolver {
+	return endpoints.ResolverFunc(func(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		url, ok := c.Endpoints[service]
+		if !ok {
+			url = c.Endpoint
+		}
+		if url == "" {
+			return endpoints.DefaultResolver().EndpointFor(service, region, opts...)
+		}
+		return endpoints.ResolvedEndpoint{URL: url, SigningRegion: region}, nil
+	})
+}
+
+// assumeRole Returns the temporary credentials of the role, cached on disk until they expire
+func assumeRole(s *session.Session, c *Config) *credentials.Credentials {
+	p := &stscreds.AssumeRoleProvider{
+		Client:   sts.New(s),
+		RoleARN:  c.RoleArn,
+		Duration: stscreds.DefaultDuration,
+	}
+	if c.ExternalID != "" {
+		p.ExternalID = aws.String(c.ExternalID)
+	}
+	if c.MFASerial != "" {
+		p.SerialNumber = aws.String(c.MFASerial)
+		p.TokenProvider = tokenProvider
+	}
+	key := strings.Join([]string{c.Profile, c.RoleArn, c.ExternalID, c.MFASerial}, "|")
+	return credentials.NewCredentials(newCachedProvider(p, key))
+}
 
-// InitSession Set AWS regin for clients
-func InitSession() *session.Session {
-	if sess == nil {
-		sess = session.New(&aws.Config{Region: aws.String(az)})
+// tokenProvider Prompts for the MFA token code if the standard input is a terminal
+func tokenProvider() (string, error) {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return "", errors.New("An MFA token code is required to assume the role, but the standard input is not a terminal")
 	}
-	return sess
+	return stscreds.StdinTokenProvider()
 }
@@ -0,0 +1,58 @@
+package sess
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		config *Config
+		ecs    string
+		ec2    string
+		sts    string
+	}{
+		{
+			config: &Config{Region: "eu-west-1"},
+			ecs:    "https://ecs.eu-west-1.amazonaws.com",
+			ec2:    "https://ec2.eu-west-1.amazonaws.com",
+			sts:    "https://sts.amazonaws.com",
+		},
+		{
+			config: &Config{Region: "eu-west-1", Endpoint: "http://localhost:4000"},
+			ecs:    "http://localhost:4000",
+			ec2:    "http://localhost:4000",
+			sts:    "http://localhost:4000",
+		},
+		{
+			config: &Config{Region: "eu-west-1", Endpoints: map[string]string{"ecs": "http://localhost:4001", "ec2": "https://localhost:4002"}},
+			ecs:    "http://localhost:4001",
+			ec2:    "https://localhost:4002",
+			sts:    "https://sts.amazonaws.com",
+		},
+		{
+			config: &Config{Region: "eu-west-1", Endpoint: "http://localhost:4000", Endpoints: map[string]string{"ec2": "http://localhost:4002"}, Insecure: true},
+			ecs:    "http://localhost:4000",
+			ec2:    "http://localhost:4002",
+			sts:    "http://localhost:4000",
+		},
+	}
+	for i, test := range tests {
+		s, err := New(test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ecs.New(s).Endpoint; got != test.ecs {
+			t.Errorf("%d. ecs endpoint = %s, want %s", i, got, test.ecs)
+		}
+		if got := ec2.New(s).Endpoint; got != test.ec2 {
+			t.Errorf("%d. ec2 endpoint = %s, want %s", i, got, test.ec2)
+		}
+		if got := sts.New(s).Endpoint; got != test.sts {
+			t.Errorf("%d. sts endpoint = %s, want %s", i, got, test.sts)
+		}
+	}
+}
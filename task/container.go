@@ -0,0 +1,281 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// ParseContainer Parses a container given as comma separated key=value fields, e.g.
+// name=nginx,image=nginx:1.9,cpu=128,memory=256,port=80:8080,link=web-app:app,mount=assets:/srv/assets:ro,essential=false
+// The env=KEY=VALUE, command, entrypoint and workdir fields set the environment and the command of the container.
+// The log-driver and log-opt=key=value fields set its log configuration.
+// The port, link, mount, env and log-opt fields can be repeated. The name and the image are required.
+// A value with commas is quoted, e.g. env="HOSTS=a,b" or env='HOSTS=a,b', or is a JSON array,
+// e.g. command=["sh","-c","exec app"]. A quoted value can not contain its quote.
+func ParseContainer(spec string) (*ecs.ContainerDefinition, error) {
+	c := &ecs.ContainerDefinition{Essential: aws.Bool(true)}
+	var env, logOpts []string
+	var logDriver string
+	fields, err := splitFields(spec)
+	if err != nil {
+		return nil, errors.New("Invalid container " + spec + ": " + err.Error())
+	}
+	for _, kv := range fields {
+		var err error
+		switch kv[0] {
+		case "name":
+			c.Name = aws.String(kv[1])
+		case "image":
+			c.Image = aws.String(kv[1])
+		case "cpu":
+			c.Cpu, err = parseInt(kv[1])
+		case "memory":
+			c.Memory, err = parseInt(kv[1])
+		case "essential":
+			var essential bool
+			essential, err = strconv.ParseBool(kv[1])
+			c.Essential = aws.Bool(essential)
+		case "port":
+			var p *ecs.PortMapping
+			p, err = ParsePort(kv[1])
+			c.PortMappings = append(c.PortMappings, p)
+		case "link":
+			c.Links = append(c.Links, aws.String(kv[1]))
+		case "mount":
+			var m *ecs.MountPoint
+			m, err = ParseMount(kv[1])
+			c.MountPoints = append(c.MountPoints, m)
+		case "env":
+			env = append(env, kv[1])
+		case "command":
+			c.Command, err = ParseCommand(kv[1])
+		case "entrypoint":
+			c.EntryPoint, err = ParseCommand(kv[1])
+		case "workdir":
+			c.WorkingDirectory = aws.String(kv[1])
+		case "log-driver":
+			logDriver = kv[1]
+		case "log-opt":
+			logOpts = append(logOpts, kv[1])
+		default:
+			err = errors.New("unknown field " + kv[0])
+		}
+		if err != nil {
+			return nil, errors.New("Invalid container " + spec + ": " + err.Error())
+		}
+	}
+	if c.Name == nil || c.Image == nil {
+		return nil, errors.New("Invalid container " + spec + ": the name and the image are required")
+	}
+	environment, err := Environment(env)
+	if err != nil {
+		return nil, errors.New("Invalid container " + spec + ": " + err.Error())
+	}
+	c.Environment = environment
+	if logDriver != "" {
+		if c.LogConfiguration, err = LogConfiguration(logDriver, logOpts); err != nil {
+			return nil, errors.New("Invalid container " + spec + ": " + err.Error())
+		}
+	} else if len(logOpts) > 0 {
+		return nil, errors.New("Invalid container " + spec + ": the log-opt field requires the log-driver field")
+	}
+	return c, nil
+}
+
+// splitFields Splits comma separated key=value fields. A value is read up to the closing quote if it starts with
+// a double or single quote, which is removed, and up to the closing bracket if it is a JSON array.
+func splitFields(spec string) ([][2]string, error) {
+	var ret [][2]string
+	for rest := spec; ; {
+		i := strings.IndexAny(rest, "=,")
+		if i < 0 || rest[i] == ',' {
+			field := rest
+			if i >= 0 {
+				field = rest[:i]
+			}
+			return nil, errors.New(field + " is not in the key=value format")
+		}
+		key := rest[:i]
+		rest = rest[i+1:]
+		var value string
+		switch {
+		case strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, "'"):
+			j := strings.IndexByte(rest[1:], rest[0])
+			if j < 0 {
+				return nil, errors.New("the value of " + key + " has no closing quote")
+			}
+			value, rest = rest[1:j+1], rest[j+2:]
+		case strings.HasPrefix(rest, "["):
+			j := jsonArrayEnd(rest)
+			if j < 0 {
+				return nil, errors.New("the value of " + key + " has no closing bracket")
+			}
+			value, rest = rest[:j], rest[j:]
+		default:
+			j := strings.IndexByte(rest, ',')
+			if j < 0 {
+				j = len(rest)
+			}
+			value, rest = rest[:j], rest[j:]
+		}
+		ret = append(ret, [2]string{key, value})
+		if rest == "" {
+			return ret, nil
+		}
+		if rest[0] != ',' {
+			return nil, errors.New("the value of " + key + " must be followed by a comma")
+		}
+		rest = rest[1:]
+	}
+}
+
+// jsonArrayEnd Returns the length of the JSON array at the start of s, or -1 if it is not closed.
+// Brackets in JSON strings are skipped.
+func jsonArrayEnd(s string) int {
+	depth := 0
+	inString := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case inString && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inString = !inString
+		case inString:
+		case s[i] == '[':
+			depth++
+		case s[i] == ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// Environment Returns the environment variables given in the KEY=VALUE form, sorted by name.
+// A later value of a variable overrides the earlier ones.
+func Environment(defs []string) ([]*ecs.KeyValuePair, error) {
+	values := map[string]string{}
+	for _, def := range defs {
+		kv := strings.SplitN(def, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.New("invalid environment variable " + def + ". The format is KEY=VALUE")
+		}
+		values[kv[0]] = kv[1]
+	}
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ret []*ecs.KeyValuePair
+	for _, name := range names {
+		ret = append(ret, &ecs.KeyValuePair{Name: aws.String(name), Value: aws.String(values[name])})
+	}
+	return ret, nil
+}
+
+// LoadEnvFile Reads the environment variables of a file with a KEY=VALUE line per variable.
+// Empty lines and lines starting with # are skipped.
+func LoadEnvFile(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, "=") {
+			return nil, errors.New("Invalid environment file " + path + ": line " + strconv.Itoa(i+1) + " is not in the KEY=VALUE format")
+		}
+		ret = append(ret, line)
+	}
+	return ret, nil
+}
+
+// ParseCommand Parses a command given as a JSON array, e.g. ["sh", "-c", "echo $HOME"], or as space separated words
+func ParseCommand(s string) ([]*string, error) {
+	if strings.HasPrefix(strings.TrimSpace(s), "[") {
+		var words []string
+		if err := json.Unmarshal([]byte(s), &words); err != nil {
+			return nil, errors.New("invalid command " + s + ": " + err.Error())
+		}
+		return aws.StringSlice(words), nil
+	}
+	return aws.StringSlice(strings.Fields(s)), nil
+}
+
+// ParsePort Parses a port mapping in the [host:]container[/protocol] format. The protocol is tcp by default.
+// A missing or 0 host port is assigned from the ephemeral port range.
+func ParsePort(spec string) (*ecs.PortMapping, error) {
+	p := &ecs.PortMapping{Protocol: aws.String("tcp")}
+	ports := spec
+	if i := strings.Index(spec, "/"); i >= 0 {
+		ports = spec[:i]
+		p.Protocol = aws.String(spec[i+1:])
+		if *p.Protocol != "tcp" && *p.Protocol != "udp" {
+			return nil, errors.New("invalid protocol in port " + spec + ". Possible values: tcp, udp")
+		}
+	}
+	hostContainer := strings.Split(ports, ":")
+	var err error
+	switch len(hostContainer) {
+	case 1:
+		p.ContainerPort, err = parseInt(hostContainer[0])
+	case 2:
+		if p.HostPort, err = parseInt(hostContainer[0]); err == nil {
+			p.ContainerPort, err = parseInt(hostContainer[1])
+		}
+	default:
+		err = errors.New("invalid port " + spec + ". The format is [host:]container[/protocol]")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParseMount Parses a mount point in the volume:path[:ro] format
+func ParseMount(spec string) (*ecs.MountPoint, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" || (len(parts) == 3 && parts[2] != "ro" && parts[2] != "rw") {
+		return nil, errors.New("invalid mount " + spec + ". The format is volume:path[:ro]")
+	}
+	return &ecs.MountPoint{
+		SourceVolume:  aws.String(parts[0]),
+		ContainerPath: aws.String(parts[1]),
+		ReadOnly:      aws.Bool(len(parts) == 3 && parts[2] == "ro"),
+	}, nil
+}
+
+// ParseVolume Parses a volume in the name=host-path format. A volume without host path is managed by Docker.
+func ParseVolume(spec string) (*ecs.Volume, error) {
+	kv := strings.SplitN(spec, "=", 2)
+	if kv[0] == "" || (len(kv) == 2 && kv[1] == "") {
+		return nil, errors.New("Invalid volume " + spec + ". The format is name=host-path")
+	}
+	v := &ecs.Volume{Name: aws.String(kv[0])}
+	if len(kv) == 2 {
+		v.Host = &ecs.HostVolumeProperties{SourcePath: aws.String(kv[1])}
+	}
+	return v, nil
+}
+
+func parseInt(s string) (*int64, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid number " + s)
+	}
+	return &i, nil
+}
@@ -0,0 +1,180 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+func TestParseContainer(t *testing.T) {
+	c, err := ParseContainer("name=nginx,image=nginx:1.9,cpu=128,memory=256,port=80:8080,port=53/udp,link=web-app:app,link=db:db,mount=assets:/srv/assets:ro,essential=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ecs.ContainerDefinition{
+		Name:      aws.String("nginx"),
+		Image:     aws.String("nginx:1.9"),
+		Cpu:       aws.Int64(128),
+		Memory:    aws.Int64(256),
+		Essential: aws.Bool(false),
+		PortMappings: []*ecs.PortMapping{
+			{HostPort: aws.Int64(80), ContainerPort: aws.Int64(8080), Protocol: aws.String("tcp")},
+			{ContainerPort: aws.Int64(53), Protocol: aws.String("udp")},
+		},
+		Links: aws.StringSlice([]string{"web-app:app", "db:db"}),
+		MountPoints: []*ecs.MountPoint{
+			{SourceVolume: aws.String("assets"), ContainerPath: aws.String("/srv/assets"), ReadOnly: aws.Bool(true)},
+		},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("ParseContainer = %v, want %v", c, want)
+	}
+}
+
+func TestParseContainerCommas(t *testing.T) {
+	c, err := ParseContainer(`name=app,image=example/web,command=["sh","-c","echo a,b] >&2"],env="HOSTS=a,b",env='JSON={"a":1,"b":2}',entrypoint=[],workdir=/srv`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := aws.StringSlice([]string{"sh", "-c", "echo a,b] >&2"}); !reflect.DeepEqual(c.Command, want) {
+		t.Errorf("command = %q, want %q", aws.StringValueSlice(c.Command), aws.StringValueSlice(want))
+	}
+	want := []*ecs.KeyValuePair{
+		{Name: aws.String("HOSTS"), Value: aws.String("a,b")},
+		{Name: aws.String("JSON"), Value: aws.String(`{"a":1,"b":2}`)},
+	}
+	if !reflect.DeepEqual(c.Environment, want) {
+		t.Errorf("environment = %v, want %v", c.Environment, want)
+	}
+	if *c.WorkingDirectory != "/srv" {
+		t.Errorf("workdir = %s, want /srv", *c.WorkingDirectory)
+	}
+}
+
+func TestParseContainerErrors(t *testing.T) {
+	for _, tc := range []struct {
+		spec, want string
+	}{
+		{"name=nginx", "the name and the image are required"},
+		{"name=nginx,image=nginx,cpu=lots", "invalid number lots"},
+		{"name=nginx,image=nginx,port=80:8080/sctp", "invalid protocol"},
+		{"name=nginx,image=nginx,port=1:2:3", "invalid port"},
+		{"name=nginx,image=nginx,mount=assets", "invalid mount"},
+		{"name=nginx,image=nginx,mount=assets:/srv:rx", "invalid mount"},
+		{"name=nginx,image=nginx,size=big", "unknown field size"},
+		{"name=nginx,image", "image is not in the key=value format"},
+		{`name=nginx,image=nginx,env="A=1,B=2`, "the value of env has no closing quote"},
+		{`name=nginx,image=nginx,command=["sh","-c"`, "the value of command has no closing bracket"},
+		{`name=nginx,image=nginx,env="A=1"x`, "the value of env must be followed by a comma"},
+	} {
+		_, err := ParseContainer(tc.spec)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ParseContainer(%q) error = %v, want %q", tc.spec, err, tc.want)
+		}
+	}
+}
+
+func TestCliRegisterTaskContainers(t *testing.T) {
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}}
+	args := []string{"-family", "web", "-image", "example/web", "-host-port", "0", "-container", "name=nginx,image=nginx,memory=64,port=80:80,link=web-app:app", "-container", "name=logs,image=fluentd,memory=64,essential=false"}
+	if _, err := commands["register"].Cmd(c, args); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range svc.registered[0].ContainerDefinitions {
+		names = append(names, *d.Name)
+	}
+	if want := []string{"web-app", "nginx", "logs"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("registered containers %v, want %v", names, want)
+	}
+
+	args = []string{"-family", "proxy", "-container", "name=nginx,image=nginx,memory=64"}
+	if _, err := commands["register"].Cmd(c, args); err != nil {
+		t.Fatal(err)
+	}
+	if defs := svc.registered[1].ContainerDefinitions; len(defs) != 1 || *defs[0].Name != "nginx" {
+		t.Errorf("registered containers %v, want only nginx", defs)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"nginx -g daemon off;", []string{"nginx", "-g", "daemon", "off;"}},
+		{`["sh", "-c", "exec app --port $PORT"]`, []string{"sh", "-c", "exec app --port $PORT"}},
+	} {
+		got, err := ParseCommand(tc.in)
+		if err != nil {
+			t.Errorf("ParseCommand(%q) error: %s", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(aws.StringValueSlice(got), tc.want) {
+			t.Errorf("ParseCommand(%q) = %q, want %q", tc.in, aws.StringValueSlice(got), tc.want)
+		}
+	}
+	if _, err := ParseCommand(`["sh", `); err == nil {
+		t.Error("ParseCommand of an invalid JSON array succeeded")
+	}
+}
+
+func TestCliRegisterTaskEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "web.env")
+	if err := ioutil.WriteFile(path, []byte("# web settings\nMODE=staging\n\nDB_URL=postgres://db/web?sslmode=disable\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}}
+	args := []string{"-family", "web", "-image", "example/web", "-env-file", path, "-env", "MODE=production", "-env", "DEBUG=",
+		"-command", `["web", "--listen", ":9000"]`, "-entrypoint", "/sbin/tini --", "-workdir", "/srv/web",
+		"-container", "name=worker,image=example/web,memory=64,env=MODE=worker,env=QUEUE=jobs,command=web work,workdir=/srv"}
+	if _, err := commands["register"].Cmd(c, args); err != nil {
+		t.Fatal(err)
+	}
+	defs := svc.registered[0].ContainerDefinitions
+	app := defs[0]
+	env := map[string]string{}
+	for _, kv := range app.Environment {
+		env[*kv.Name] = *kv.Value
+	}
+	if want := map[string]string{"MODE": "production", "DB_URL": "postgres://db/web?sslmode=disable", "DEBUG": ""}; !reflect.DeepEqual(env, want) {
+		t.Errorf("app environment = %v, want %v", env, want)
+	}
+	if *app.Environment[0].Name != "DB_URL" {
+		t.Errorf("app environment is not sorted: %v", app.Environment)
+	}
+	if got := aws.StringValueSlice(app.Command); !reflect.DeepEqual(got, []string{"web", "--listen", ":9000"}) {
+		t.Errorf("app command = %q", got)
+	}
+	if got := aws.StringValueSlice(app.EntryPoint); !reflect.DeepEqual(got, []string{"/sbin/tini", "--"}) {
+		t.Errorf("app entry point = %q", got)
+	}
+	if *app.WorkingDirectory != "/srv/web" {
+		t.Errorf("app working directory = %s", *app.WorkingDirectory)
+	}
+	worker := defs[1]
+	if len(worker.Environment) != 2 || *worker.Environment[0].Value != "worker" || *worker.Environment[1].Value != "jobs" {
+		t.Errorf("worker environment = %v", worker.Environment)
+	}
+	if got := aws.StringValueSlice(worker.Command); !reflect.DeepEqual(got, []string{"web", "work"}) || *worker.WorkingDirectory != "/srv" {
+		t.Errorf("worker command = %q in %s", got, *worker.WorkingDirectory)
+	}
+
+	if _, err := commands["register"].Cmd(c, []string{"-family", "web", "-image", "example/web", "-env", "MODE"}); err == nil {
+		t.Error("register with an invalid environment variable succeeded")
+	}
+}
@@ -0,0 +1,142 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+// DescribeTaskDef Describes a task definition revision
+func DescribeTaskDef(svc ecsiface.ECSAPI, taskDef *string) (*ecs.DescribeTaskDefinitionOutput, error) {
+	return svc.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: taskDef})
+}
+
+// RegisterInput Returns the input registering a copy of a task definition
+func RegisterInput(td *ecs.TaskDefinition) (*ecs.RegisterTaskDefinitionInput, error) {
+	// The fields set by ECS are not part of the input, so they are left out
+	data, err := json.Marshal(td)
+	if err != nil {
+		return nil, err
+	}
+	var params ecs.RegisterTaskDefinitionInput
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// definitionLines Renders a task definition readably
+func definitionLines(td *ecs.TaskDefinition) []string {
+	ret := []string{
+		fmt.Sprintf("%s:%d %s %s", aws.StringValue(td.Family), aws.Int64Value(td.Revision), aws.StringValue(td.Status), aws.StringValue(td.TaskDefinitionArn)),
+	}
+	if td.NetworkMode != nil || td.TaskRoleArn != nil {
+		ret = append(ret, "network mode: "+aws.StringValue(td.NetworkMode)+" task role: "+aws.StringValue(td.TaskRoleArn))
+	}
+	for _, v := range td.Volumes {
+		line := "volume " + aws.StringValue(v.Name)
+		if v.Host != nil && v.Host.SourcePath != nil {
+			line += ": host " + *v.Host.SourcePath
+		}
+		ret = append(ret, line)
+	}
+	for _, c := range td.ContainerDefinitions {
+		line := fmt.Sprintf("container %s: image %s cpu %d memory %d", aws.StringValue(c.Name), aws.StringValue(c.Image), aws.Int64Value(c.Cpu), aws.Int64Value(c.Memory))
+		if aws.BoolValue(c.Essential) {
+			line += " essential"
+		}
+		ret = append(ret, line)
+		var ports []string
+		for _, p := range c.PortMappings {
+			ports = append(ports, fmt.Sprintf("%d:%d/%s", aws.Int64Value(p.HostPort), aws.Int64Value(p.ContainerPort), aws.StringValue(p.Protocol)))
+		}
+		var mounts []string
+		for _, m := range c.MountPoints {
+			mount := aws.StringValue(m.SourceVolume) + ":" + aws.StringValue(m.ContainerPath)
+			if aws.BoolValue(m.ReadOnly) {
+				mount += ":ro"
+			}
+			mounts = append(mounts, mount)
+		}
+		var env []string
+		for _, kv := range c.Environment {
+			env = append(env, aws.StringValue(kv.Name)+"="+aws.StringValue(kv.Value))
+		}
+		for _, f := range []struct {
+			name   string
+			values []string
+		}{
+			{"ports", ports},
+			{"links", aws.StringValueSlice(c.Links)},
+			{"mounts", mounts},
+			{"env", env},
+			{"entrypoint", aws.StringValueSlice(c.EntryPoint)},
+			{"command", aws.StringValueSlice(c.Command)},
+		} {
+			if len(f.values) > 0 {
+				ret = append(ret, "  "+f.name+": "+strings.Join(f.values, " "))
+			}
+		}
+		if c.WorkingDirectory != nil {
+			ret = append(ret, "  workdir: "+*c.WorkingDirectory)
+		}
+		if c.LogConfiguration != nil {
+			var opts []string
+			for k, v := range c.LogConfiguration.Options {
+				opts = append(opts, k+"="+aws.StringValue(v))
+			}
+			sort.Strings(opts)
+			ret = append(ret, "  log: "+strings.TrimSpace(aws.StringValue(c.LogConfiguration.LogDriver)+" "+strings.Join(opts, " ")))
+		}
+	}
+	return ret
+}
+
+// describeTaskDefOptions The parameters of the definition-desc command
+type describeTaskDefOptions struct {
+	taskDef string
+	export  string
+}
+
+func (o *describeTaskDefOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	c.StringVar(&o.taskDef, "task-definition", "", "The family for the latest ACTIVE revision, family and revision (family:revision) or full Amazon Resource Name (ARN) of the task definition to describe.")
+	c.StringVar(&o.export, "export", "", "Write the task definition to this file in the format of the register -file parameter and of aws ecs register-task-definition --cli-input-json: JSON if the name ends with .json, YAML otherwise.")
+	return c
+}
+
+func cliDescribeTaskDefParams(args []string) *flag.FlagSet {
+	return new(describeTaskDefOptions).flags(args)
+}
+
+func cliDescribeTaskDef(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o describeTaskDefOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if o.taskDef == "" {
+		return nil, errors.New("The task-definition parameter is required")
+	}
+	resp, err := DescribeTaskDef(c.ECS, &o.taskDef)
+	if err != nil {
+		return nil, err
+	}
+	if o.export != "" {
+		params, err := RegisterInput(resp.TaskDefinition)
+		if err != nil {
+			return nil, err
+		}
+		if err := WriteDefinition(o.export, params); err != nil {
+			return nil, err
+		}
+	}
+	return cli.NewResult(resp, definitionLines(resp.TaskDefinition)...), nil
+}
@@ -0,0 +1,90 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/fake"
+)
+
+func TestCliDescribeTaskDef(t *testing.T) {
+	b := fake.New(fake.DefaultState(), "us-east-1")
+	var containers []*ecs.ContainerDefinition
+	for _, s := range []string{
+		"name=web,image=example/web:42,cpu=256,memory=512,port=80:9000,link=statsd,env=MODE=production,mount=assets:/srv/assets:ro,command=bin/web -v",
+		"name=statsd,image=statsd,memory=64,essential=false",
+	} {
+		c, err := ParseContainer(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, c)
+	}
+	containers[0].DockerLabels = map[string]*string{"Team": aws.String("web")}
+	volumes := []*ecs.Volume{{Name: aws.String("assets"), Host: &ecs.HostVolumeProperties{SourcePath: aws.String("/srv/assets")}}}
+	if _, err := RegisterTask(b, Definition(aws.String("web"), containers, volumes)); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"web.json", "web.yaml"} {
+		path := filepath.Join(dir, name)
+		ret, err := commands["definition-desc"].Cmd(b.Clients(), []string{"-task-definition", "web:1", "-export", path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"web:1 ACTIVE " + taskDefinitionArn("web", 1),
+			"volume assets: host /srv/assets",
+			"container web: image example/web:42 cpu 256 memory 512 essential",
+			"  ports: 80:9000/tcp",
+			"  links: statsd",
+			"  mounts: assets:/srv/assets:ro",
+			"  env: MODE=production",
+			"  command: bin/web -v",
+			"container statsd: image statsd cpu 0 memory 64",
+		}
+		if !reflect.DeepEqual(ret.Lines, want) {
+			t.Errorf("lines =\n%q\nwant\n%q", ret.Lines, want)
+		}
+
+		// The keys are the ones of the API
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "containerDefinitions") || strings.Contains(string(data), "ContainerDefinitions") {
+			t.Errorf("%s keys are not camelCase:\n%s", name, data)
+		}
+		if !strings.Contains(string(data), "dockerLabels") || !strings.Contains(string(data), "Team") {
+			t.Errorf("%s does not keep the keys of the docker labels:\n%s", name, data)
+		}
+
+		// The exported file registers the same task definition
+		params, err := LoadDefinition(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := RegisterTask(b, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes, err := DiffTaskDefs(ret.Value.(*ecs.DescribeTaskDefinitionOutput).TaskDefinition, resp.TaskDefinition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) > 0 {
+			t.Errorf("%s registers a different task definition: %q", name, changeLines(changes))
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+// Change A field that differs between two task definitions
+type Change struct {
+	// Path The field, e.g. ContainerDefinitions[web].Environment[MODE] or ContainerDefinitions[web].PortMappings[9000/tcp].HostPort.
+	// The containers, volumes and ulimits are keyed by name, the port mappings by container port and protocol and the mount points by container path.
+	Path string
+	// Old The value in the first task definition, missing if the field was added
+	Old interface{}
+	// New The value in the second task definition, missing if the field was removed
+	New interface{}
+}
+
+// DiffOutput The changes between two task definitions
+type DiffOutput struct {
+	From    string
+	To      string
+	Changes []*Change
+}
+
+// The fields set by ECS, which always differ between revisions
+var metadataFields = []string{"TaskDefinitionArn", "Revision", "Status", "RequiresAttributes", "Compatibilities", "RegisteredAt", "RegisteredBy", "DeregisteredAt"}
+
+// The fields identifying the items of the lists
+var listKeys = map[string][]string{
+	"ContainerDefinitions": {"Name"},
+	"Volumes":              {"Name"},
+	"Ulimits":              {"Name"},
+	"Secrets":              {"Name"},
+	"PortMappings":         {"ContainerPort", "Protocol"},
+	"MountPoints":          {"ContainerPath"},
+	"VolumesFrom":          {"SourceContainer"},
+	"ExtraHosts":           {"Hostname"},
+}
+
+// DiffTaskDefs Returns the changes between two task definitions
+func DiffTaskDefs(from *ecs.TaskDefinition, to *ecs.TaskDefinition) ([]*Change, error) {
+	before, err := flattenTaskDef(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flattenTaskDef(to)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for p := range before {
+		paths = append(paths, p)
+	}
+	for p := range after {
+		if _, ok := before[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	var ret []*Change
+	for _, p := range paths {
+		if !reflect.DeepEqual(before[p], after[p]) {
+			ret = append(ret, &Change{Path: p, Old: before[p], New: after[p]})
+		}
+	}
+	return ret, nil
+}
+
+// flattenTaskDef Returns the fields of a task definition by path, leaving out the metadata
+func flattenTaskDef(td *ecs.TaskDefinition) (map[string]interface{}, error) {
+	data, err := cli.Plain(td)
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := data.(map[string]interface{})
+	for _, f := range metadataFields {
+		delete(fields, f)
+	}
+	ret := map[string]interface{}{}
+	flatten("", "", fields, ret)
+	return ret, nil
+}
+
+// flatten Adds the scalar fields and the lists of scalars of v to out by path
+func flatten(path string, name string, v interface{}, out map[string]interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			flatten(p, k, e, out)
+		}
+	case []interface{}:
+		if name == "Environment" {
+			for _, e := range t {
+				kv, _ := e.(map[string]interface{})
+				out[path+"["+fmt.Sprint(kv["Name"])+"]"] = kv["Value"]
+			}
+			return
+		}
+		if len(t) > 0 {
+			if _, ok := t[0].(map[string]interface{}); !ok {
+				out[path] = t
+				return
+			}
+		}
+		for i, e := range t {
+			flatten(path+"["+itemKey(name, i, e)+"]", "", e, out)
+		}
+	default:
+		out[path] = t
+	}
+}
+
+// itemKey Returns the key of an item of a list: the identifying fields, or the index
+func itemKey(name string, i int, item interface{}) string {
+	m, _ := item.(map[string]interface{})
+	var key []string
+	for _, k := range listKeys[name] {
+		if v, ok := m[k]; ok {
+			key = append(key, fmt.Sprint(v))
+		}
+	}
+	if len(key) == 0 {
+		return fmt.Sprint(i)
+	}
+	return strings.Join(key, "/")
+}
+
+// DiffTasks Returns the changes between two task definition revisions
+func DiffTasks(svc ecsiface.ECSAPI, from *string, to *string) (*DiffOutput, error) {
+	a, err := svc.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: from})
+	if err != nil {
+		return nil, err
+	}
+	b, err := svc.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: to})
+	if err != nil {
+		return nil, err
+	}
+	changes, err := DiffTaskDefs(a.TaskDefinition, b.TaskDefinition)
+	if err != nil {
+		return nil, err
+	}
+	return &DiffOutput{
+		From:    aws.StringValue(a.TaskDefinition.TaskDefinitionArn),
+		To:      aws.StringValue(b.TaskDefinition.TaskDefinitionArn),
+		Changes: changes,
+	}, nil
+}
+
+// changeLines Returns a line per change: + for the added, - for the removed and ~ for the modified fields
+func changeLines(changes []*Change) []string {
+	var ret = make([]string, len(changes))
+	for i, c := range changes {
+		switch {
+		case c.Old == nil:
+			ret[i] = "+ " + c.Path + ": " + formatValue(c.New)
+		case c.New == nil:
+			ret[i] = "- " + c.Path + ": " + formatValue(c.Old)
+		default:
+			ret[i] = "~ " + c.Path + ": " + formatValue(c.Old) + " -> " + formatValue(c.New)
+		}
+	}
+	return ret
+}
+
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+// diffTasksOptions The parameters of the diff command
+type diffTasksOptions struct {
+	from string
+	to   string
+}
+
+func (o *diffTasksOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	c.StringVar(&o.from, "from", "", "The family and revision (family:revision) or full Amazon Resource Name (ARN) of the old task definition.")
+	c.StringVar(&o.to, "to", "", "The family and revision (family:revision) or full Amazon Resource Name (ARN) of the new task definition. Defaults to the latest ACTIVE revision of the family of the old one.")
+	return c
+}
+
+func cliDiffTasksParams(args []string) *flag.FlagSet {
+	return new(diffTasksOptions).flags(args)
+}
+
+func cliDiffTasks(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o diffTasksOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if o.from == "" {
+		return nil, errors.New("The from parameter is required")
+	}
+	if o.to == "" {
+		family := o.from[strings.LastIndex(o.from, "/")+1:]
+		if i := strings.Index(family, ":"); i >= 0 {
+			family = family[:i]
+		}
+		o.to = family
+	}
+	resp, err := DiffTasks(c.ECS, &o.from, &o.to)
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, changeLines(resp.Changes)...), nil
+}
@@ -0,0 +1,64 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/fake"
+)
+
+func TestDiffTasks(t *testing.T) {
+	b := fake.New(fake.DefaultState(), "us-east-1")
+	for _, spec := range [][]string{
+		{"name=web,image=example/web:41,cpu=256,memory=512,port=80:9000,env=MODE=production,env=DEBUG=1,mount=assets:/srv/assets", "name=statsd,image=statsd,memory=64"},
+		{"name=web,image=example/web:42,cpu=256,memory=1024,port=0:9000,env=MODE=production,env=WORKERS=4,mount=assets:/srv/assets:ro", "name=proxy,image=nginx,memory=64,command=nginx -g daemon off;"},
+	} {
+		var containers []*ecs.ContainerDefinition
+		for _, s := range spec {
+			c, err := ParseContainer(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			containers = append(containers, c)
+		}
+		volumes := []*ecs.Volume{{Name: aws.String("assets"), Host: &ecs.HostVolumeProperties{SourcePath: aws.String("/srv/assets")}}}
+		if _, err := RegisterTask(b, Definition(aws.String("web"), containers, volumes)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ret, err := commands["diff"].Cmd(b.Clients(), []string{"-from", "web:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := ret.Value.(*DiffOutput)
+	if out.From != taskDefinitionArn("web", 1) || out.To != taskDefinitionArn("web", 2) {
+		t.Errorf("diff from %s to %s, want web:1 to web:2", out.From, out.To)
+	}
+	want := []string{
+		"+ ContainerDefinitions[proxy].Command: [\"nginx\",\"-g\",\"daemon\",\"off;\"]",
+		"+ ContainerDefinitions[proxy].Essential: true",
+		"+ ContainerDefinitions[proxy].Image: nginx",
+		"+ ContainerDefinitions[proxy].Memory: 64",
+		"+ ContainerDefinitions[proxy].Name: proxy",
+		"- ContainerDefinitions[statsd].Essential: true",
+		"- ContainerDefinitions[statsd].Image: statsd",
+		"- ContainerDefinitions[statsd].Memory: 64",
+		"- ContainerDefinitions[statsd].Name: statsd",
+		"- ContainerDefinitions[web].Environment[DEBUG]: 1",
+		"+ ContainerDefinitions[web].Environment[WORKERS]: 4",
+		"~ ContainerDefinitions[web].Image: example/web:41 -> example/web:42",
+		"~ ContainerDefinitions[web].Memory: 512 -> 1024",
+		"~ ContainerDefinitions[web].MountPoints[/srv/assets].ReadOnly: false -> true",
+		"~ ContainerDefinitions[web].PortMappings[9000/tcp].HostPort: 80 -> 0",
+	}
+	if !reflect.DeepEqual(ret.Lines, want) {
+		t.Errorf("diff lines =\n%q\nwant\n%q", ret.Lines, want)
+	}
+
+	if _, err := commands["diff"].Cmd(b.Clients(), []string{"-from", "web:1", "-to", "web:9"}); err == nil {
+		t.Error("diff with a missing revision succeeded")
+	}
+}
@@ -0,0 +1,117 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/cli"
+	"github.com/gawkermedia/ecs/vars"
+	"github.com/ghodss/yaml"
+)
+
+// LoadDefinition Reads a task definition from a JSON or YAML file. The format is the input of the
+// RegisterTaskDefinition API call, as printed by `aws ecs register-task-definition --generate-cli-skeleton`.
+// The variables are substituted before parsing.
+func LoadDefinition(path string, v vars.Vars) (*ecs.RegisterTaskDefinitionInput, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = v.Expand(path, data)
+	if err != nil {
+		return nil, err
+	}
+	params, err := ParseDefinition(data)
+	if err != nil {
+		return nil, errors.New("Invalid task definition file " + path + ": " + err.Error())
+	}
+	return params, nil
+}
+
+// ParseDefinition Parses and validates a JSON or YAML task definition. Unknown fields are rejected.
+func ParseDefinition(data []byte) (*ecs.RegisterTaskDefinitionInput, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	var params ecs.RegisterTaskDefinitionInput
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&params); err != nil {
+		return nil, err
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// WriteDefinition Writes a task definition to a JSON file if the name ends with .json, to a YAML file otherwise.
+// The keys are the camelCase ones of the API, so the file is also the input of `aws ecs register-task-definition --cli-input-json`.
+func WriteDefinition(path string, params *ecs.RegisterTaskDefinitionInput) error {
+	plain, err := cli.Plain(params)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(camelCase(plain, reflect.TypeOf(params)))
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Indent(&out, data, "", "  ")
+		out.WriteString("\n")
+	} else {
+		data, err = yaml.JSONToYAML(data)
+		out.Write(data)
+	}
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out.Bytes(), 0644)
+}
+
+// camelCase Renames the fields of the structs of type t in a plain value to the names of the API,
+// e.g. ContainerDefinitions to containerDefinitions. The keys of the maps, like the docker labels, are kept.
+func camelCase(value interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if t.Kind() == reflect.Map {
+				ret[k] = camelCase(item, t.Elem())
+				continue
+			}
+			field, ok := t.FieldByName(k)
+			if !ok {
+				ret[k] = item
+				continue
+			}
+			name := field.Tag.Get("locationName")
+			if name == "" {
+				r, size := utf8.DecodeRuneInString(k)
+				name = string(unicode.ToLower(r)) + k[size:]
+			}
+			ret[name] = camelCase(item, field.Type)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = camelCase(item, t.Elem())
+		}
+		return ret
+	}
+	return value
+}
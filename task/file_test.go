@@ -0,0 +1,141 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gawkermedia/ecs/cli"
+	"github.com/gawkermedia/ecs/vars"
+)
+
+const yamlDefinition = `
+family: web
+volumes:
+  - name: assets
+    host:
+      sourcePath: /srv/assets
+containerDefinitions:
+  - name: app
+    image: example/web:1.2
+    cpu: 256
+    memory: 512
+    essential: true
+    environment:
+      - name: MODE
+        value: production
+    portMappings:
+      - containerPort: 9000
+        hostPort: 80
+    mountPoints:
+      - sourceVolume: assets
+        containerPath: /var/www/assets
+        readOnly: true
+    ulimits:
+      - name: nofile
+        softLimit: 4096
+        hardLimit: 8192
+    logConfiguration:
+      logDriver: syslog
+  - name: worker
+    image: example/worker:1.2
+    memory: 128
+    links:
+      - app:app
+`
+
+func TestParseDefinitionYAML(t *testing.T) {
+	params, err := ParseDefinition([]byte(yamlDefinition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *params.Family != "web" || len(params.ContainerDefinitions) != 2 || len(params.Volumes) != 1 {
+		t.Fatalf("unexpected definition %v", params)
+	}
+	app := params.ContainerDefinitions[0]
+	if *app.Environment[0].Value != "production" || *app.MountPoints[0].ContainerPath != "/var/www/assets" || !*app.MountPoints[0].ReadOnly {
+		t.Errorf("unexpected app container %v", app)
+	}
+	if *app.Ulimits[0].HardLimit != 8192 || *app.LogConfiguration.LogDriver != "syslog" {
+		t.Errorf("unexpected app container %v", app)
+	}
+	if *params.ContainerDefinitions[1].Links[0] != "app:app" {
+		t.Errorf("unexpected worker container %v", params.ContainerDefinitions[1])
+	}
+}
+
+func TestParseDefinitionJSON(t *testing.T) {
+	params, err := ParseDefinition([]byte(`{"family": "web", "containerDefinitions": [{"name": "app", "image": "nginx", "memory": 128}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *params.ContainerDefinitions[0].Image != "nginx" {
+		t.Errorf("unexpected definition %v", params)
+	}
+}
+
+func TestParseDefinitionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		def  string
+		want string
+	}{
+		{"family: web\ncontainerDefinitions:\n  - name: app\n    imag: nginx\n", `unknown field "imag"`},
+		{"family: web\ncontainerDefinitions:\n  - name: app\n    memory: lots\n", "cannot unmarshal string"},
+		{"containerDefinitions:\n  - name: app\n", "missing required field, RegisterTaskDefinitionInput.Family"},
+		{"family: web\ncontainerDefinitions:\n  - name: app\n    ulimits:\n      - name: nofile\n", "ContainerDefinitions[0].Ulimits[0].HardLimit"},
+	} {
+		_, err := ParseDefinition([]byte(tc.def))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ParseDefinition(%q) error = %v, want %q", tc.def, err, tc.want)
+		}
+	}
+}
+
+func TestCliRegisterTaskFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "taskdef.yaml")
+	if err := ioutil.WriteFile(path, []byte(yamlDefinition), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}}
+	if _, err := commands["register"].Cmd(c, []string{"-file", path, "-family", "web-staging"}); err != nil {
+		t.Fatal(err)
+	}
+	params := svc.registered[0]
+	if *params.Family != "web-staging" || len(params.ContainerDefinitions) != 2 {
+		t.Errorf("unexpected registered definition %v", params)
+	}
+	if _, err := commands["register"].Cmd(c, []string{"-file", filepath.Join(dir, "missing.yaml")}); err == nil {
+		t.Error("register of a missing file succeeded")
+	}
+}
+
+func TestLoadDefinitionVars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "taskdef.yaml.tmpl")
+	def := "family: web\ncontainerDefinitions:\n  - name: app\n    image: example/web:${ECS_TEST_SHA}\n    memory: {{.ECS_TEST_MEMORY}}\n"
+	if err := ioutil.WriteFile(path, []byte(def), 0644); err != nil {
+		t.Fatal(err)
+	}
+	params, err := LoadDefinition(path, vars.Vars{"ECS_TEST_SHA": "abc123", "ECS_TEST_MEMORY": "256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app := params.ContainerDefinitions[0]; *app.Image != "example/web:abc123" || *app.Memory != 256 {
+		t.Errorf("unexpected app container %v", app)
+	}
+	if _, err := LoadDefinition(path, vars.Vars{}); err == nil || err.Error() != "Unresolved variables in "+path+": ECS_TEST_MEMORY, ECS_TEST_SHA" {
+		t.Errorf("LoadDefinition without the variables error = %v", err)
+	}
+}
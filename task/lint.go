@@ -0,0 +1,235 @@
+package task
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+// MinMemory The minimum memory of a container in MiB accepted by Docker
+const MinMemory = 4
+
+// Problem A problem found by Lint in a task definition
+type Problem struct {
+	// Path The field, e.g. ContainerDefinitions[1].Links[0]
+	Path    string
+	Message string
+}
+
+// Capacity The largest resources registered by a container instance of a cluster
+type Capacity struct {
+	CPU    int64
+	Memory int64
+}
+
+// ClusterCapacity Returns the largest resources registered by a container instance of the cluster,
+// or nil if the cluster has no container instance
+func ClusterCapacity(svc ecsiface.ECSAPI, cluster *string) (*Capacity, error) {
+	var arns []*string
+	params := &ecs.ListContainerInstancesInput{Cluster: cluster}
+	for {
+		resp, err := svc.ListContainerInstances(params)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, resp.ContainerInstanceArns...)
+		if resp.NextToken == nil {
+			break
+		}
+		params.NextToken = resp.NextToken
+	}
+	if len(arns) == 0 {
+		return nil, nil
+	}
+	ret := &Capacity{}
+	for i := 0; i < len(arns); i += 100 {
+		resp, err := svc.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{Cluster: cluster, ContainerInstances: arns[i:minInt(i+100, len(arns))]})
+		if err != nil {
+			return nil, err
+		}
+		for _, ci := range resp.ContainerInstances {
+			for _, r := range ci.RegisteredResources {
+				v := aws.Int64Value(r.IntegerValue)
+				switch aws.StringValue(r.Name) {
+				case "CPU":
+					if v > ret.CPU {
+						ret.CPU = v
+					}
+				case "MEMORY":
+					if v > ret.Memory {
+						ret.Memory = v
+					}
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+// Lint Returns the problems of a task definition which the API accepts but which fail when the task starts:
+// the invalid parameters, a memory below MinMemory, links to unknown containers, mounts of undeclared volumes,
+// duplicate host ports and no essential container. The cpu and memory of the task are checked against the
+// capacity unless it is nil.
+func Lint(params *ecs.RegisterTaskDefinitionInput, capacity *Capacity) []*Problem {
+	var ret []*Problem
+	add := func(path string, format string, a ...interface{}) {
+		ret = append(ret, &Problem{Path: path, Message: fmt.Sprintf(format, a...)})
+	}
+	if err := params.Validate(); err != nil {
+		if errs, ok := err.(request.ErrInvalidParams); ok {
+			for _, e := range errs.OrigErrs() {
+				if p, ok := e.(request.ErrInvalidParam); ok {
+					// The message ends with the field, e.g. "missing required field, RegisterTaskDefinitionInput.Family."
+					add(strings.TrimPrefix(p.Field(), "RegisterTaskDefinitionInput."), "%s", strings.SplitN(p.Message(), ",", 2)[0])
+				} else {
+					add("", "%s", e.Error())
+				}
+			}
+		} else {
+			add("", "%s", err.Error())
+		}
+	}
+
+	volumes := map[string]bool{}
+	for _, v := range params.Volumes {
+		volumes[aws.StringValue(v.Name)] = true
+	}
+	names := map[string]bool{}
+	for _, c := range params.ContainerDefinitions {
+		names[aws.StringValue(c.Name)] = true
+	}
+
+	var cpu, memory int64
+	essential := false
+	hostPorts := map[string]string{}
+	for i, c := range params.ContainerDefinitions {
+		path := fmt.Sprintf("ContainerDefinitions[%d]", i)
+		if c.Essential == nil || *c.Essential {
+			essential = true
+		}
+		cpu += aws.Int64Value(c.Cpu)
+		switch {
+		case c.Memory != nil:
+			memory += *c.Memory
+		case c.MemoryReservation != nil:
+			memory += *c.MemoryReservation
+		case params.Memory == nil:
+			add(path, "The container has neither memory nor memory reservation")
+		}
+		if c.Memory != nil && *c.Memory < MinMemory {
+			add(path+".Memory", "%d MiB is below the minimum of %d MiB", *c.Memory, MinMemory)
+		}
+		if c.MemoryReservation != nil && *c.MemoryReservation < MinMemory {
+			add(path+".MemoryReservation", "%d MiB is below the minimum of %d MiB", *c.MemoryReservation, MinMemory)
+		}
+		for j, l := range aws.StringValueSlice(c.Links) {
+			name := strings.SplitN(l, ":", 2)[0]
+			switch {
+			case name == aws.StringValue(c.Name):
+				add(fmt.Sprintf("%s.Links[%d]", path, j), "The container links to itself")
+			case !names[name]:
+				add(fmt.Sprintf("%s.Links[%d]", path, j), "There is no %s container", name)
+			}
+		}
+		for j, m := range c.MountPoints {
+			if v := aws.StringValue(m.SourceVolume); !volumes[v] {
+				add(fmt.Sprintf("%s.MountPoints[%d].SourceVolume", path, j), "There is no %s volume", v)
+			}
+		}
+		for j, p := range c.PortMappings {
+			if aws.Int64Value(p.HostPort) == 0 {
+				continue
+			}
+			protocol := aws.StringValue(p.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			port := fmt.Sprintf("%d/%s", *p.HostPort, protocol)
+			portPath := fmt.Sprintf("%s.PortMappings[%d].HostPort", path, j)
+			if other, ok := hostPorts[port]; ok {
+				add(portPath, "The host port %s is already mapped by %s", port, other)
+				continue
+			}
+			hostPorts[port] = portPath
+		}
+	}
+	if len(params.ContainerDefinitions) > 0 && !essential {
+		add("ContainerDefinitions", "No container is essential")
+	}
+	if capacity != nil {
+		if cpu > capacity.CPU {
+			add("ContainerDefinitions", "The containers reserve %d cpu units, more than the %d of the largest container instance", cpu, capacity.CPU)
+		}
+		if memory > capacity.Memory {
+			add("ContainerDefinitions", "The containers reserve %d MiB of memory, more than the %d MiB of the largest container instance", memory, capacity.Memory)
+		}
+	}
+	return ret
+}
+
+// problemLines Returns a line per problem: the path and the message
+func problemLines(problems []*Problem) []string {
+	var ret = make([]string, len(problems))
+	for i, p := range problems {
+		ret[i] = p.Path + ": " + p.Message
+	}
+	return ret
+}
+
+// lintError Returns an error listing the problems, or nil if there is none
+func lintError(problems []*Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("Invalid task definition:\n  " + strings.Join(problemLines(problems), "\n  "))
+}
+
+// lint Returns the problems of the task definition. The capacity of the cluster is checked only if
+// the cluster parameter is set, since the other commands default to the default cluster.
+func (o *registerTaskOptions) lint(c *cli.Clients, f *flag.FlagSet, params *ecs.RegisterTaskDefinitionInput) ([]*Problem, error) {
+	var capacity *Capacity
+	var err error
+	f.Visit(func(fl *flag.Flag) {
+		if fl.Name == "cluster" {
+			capacity, err = ClusterCapacity(c.ECS, &o.cluster)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Lint(params, capacity), nil
+}
+
+func cliLintTaskParams(args []string) *flag.FlagSet {
+	return new(registerTaskOptions).flags(args)
+}
+
+func cliLintTask(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o registerTaskOptions
+	f := o.flags(args)
+	if err := f.Parse(args); err != nil {
+		return nil, err
+	}
+	params, err := o.build(c)
+	if err != nil {
+		return nil, err
+	}
+	problems, err := o.lint(c, f, params)
+	if err != nil {
+		return nil, err
+	}
+	if problems == nil {
+		problems = []*Problem{}
+	}
+	if len(problems) > 0 {
+		err = fmt.Errorf("Problems found in the task definition: %d", len(problems))
+	}
+	return cli.NewResult(problems, problemLines(problems)...), err
+}
@@ -0,0 +1,85 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/fake"
+)
+
+func TestLint(t *testing.T) {
+	var containers []*ecs.ContainerDefinition
+	for _, s := range []string{
+		"name=web,image=example/web,cpu=1024,memory=2,port=80:9000,link=statsd,link=cache:redis,mount=assets:/srv/assets",
+		"name=proxy,image=nginx,cpu=1536,memory=64,port=80:80,port=8125:8125/udp,link=proxy,essential=false",
+		"name=statsd,image=statsd,memory=64,port=8125:8125/udp,essential=false",
+	} {
+		c, err := ParseContainer(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, c)
+	}
+	containers[0].Essential = aws.Bool(false)
+	containers = append(containers, &ecs.ContainerDefinition{Image: aws.String("busybox"), Essential: aws.Bool(false)})
+	params := &ecs.RegisterTaskDefinitionInput{ContainerDefinitions: containers}
+
+	var got []string
+	for _, p := range Lint(params, &Capacity{CPU: 2048, Memory: 3768}) {
+		got = append(got, p.Path+": "+p.Message)
+	}
+	want := []string{
+		"Family: missing required field",
+		"ContainerDefinitions[0].Memory: 2 MiB is below the minimum of 4 MiB",
+		"ContainerDefinitions[0].Links[1]: There is no cache container",
+		"ContainerDefinitions[0].MountPoints[0].SourceVolume: There is no assets volume",
+		"ContainerDefinitions[1].Links[0]: The container links to itself",
+		"ContainerDefinitions[1].PortMappings[0].HostPort: The host port 80/tcp is already mapped by ContainerDefinitions[0].PortMappings[0].HostPort",
+		"ContainerDefinitions[2].PortMappings[0].HostPort: The host port 8125/udp is already mapped by ContainerDefinitions[1].PortMappings[1].HostPort",
+		"ContainerDefinitions[3]: The container has neither memory nor memory reservation",
+		"ContainerDefinitions: No container is essential",
+		"ContainerDefinitions: The containers reserve 2560 cpu units, more than the 2048 of the largest container instance",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint =\n%q\nwant\n%q", got, want)
+	}
+
+	valid, err := ParseContainer("name=web,image=example/web,memory=128")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := Lint(&ecs.RegisterTaskDefinitionInput{Family: aws.String("web"), ContainerDefinitions: []*ecs.ContainerDefinition{valid}}, nil); len(problems) > 0 {
+		t.Errorf("Lint of a valid task definition = %q", problemLines(problems))
+	}
+}
+
+func TestCliLintTask(t *testing.T) {
+	b := fake.New(fake.DefaultState(), "us-east-1")
+	c := b.Clients()
+	args := []string{"-family", "web", "-image", "example/web", "-cpu", "4096", "-cluster", "default"}
+	ret, err := commands["lint"].Cmd(c, args)
+	if err == nil {
+		t.Fatal("lint of a task above the capacity of the cluster succeeded")
+	}
+	want := []string{"ContainerDefinitions: The containers reserve 4096 cpu units, more than the 2048 of the largest container instance"}
+	if !reflect.DeepEqual(ret.Lines, want) {
+		t.Errorf("lint lines = %q, want %q", ret.Lines, want)
+	}
+	if _, err := commands["register"].Cmd(c, args); err == nil {
+		t.Error("register of a task above the capacity of the cluster succeeded")
+	}
+	if _, err := commands["register"].Cmd(c, append(args, "-skip-lint")); err != nil {
+		t.Errorf("register with skip-lint failed: %s", err)
+	}
+
+	// Without the cluster parameter, the capacity is not checked
+	ret, err = commands["lint"].Cmd(c, args[:6])
+	if err != nil {
+		t.Fatalf("lint failed: %s", err)
+	}
+	if problems := ret.Value.([]*Problem); len(problems) > 0 {
+		t.Errorf("lint problems = %q, want none", ret.Lines)
+	}
+}
@@ -0,0 +1,64 @@
+package task
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// LogConfiguration Returns the log configuration of a Docker log driver with the options given in the key=value form
+func LogConfiguration(driver string, opts []string) (*ecs.LogConfiguration, error) {
+	valid := false
+	for _, d := range ecs.LogDriver_Values() {
+		valid = valid || d == driver
+	}
+	if !valid {
+		return nil, errors.New("Unknown log driver: " + driver + ". Possible values: " + strings.Join(ecs.LogDriver_Values(), ", "))
+	}
+	l := &ecs.LogConfiguration{LogDriver: aws.String(driver)}
+	for _, opt := range opts {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.New("Invalid log option: " + opt + ". The format is key=value")
+		}
+		if l.Options == nil {
+			l.Options = map[string]*string{}
+		}
+		l.Options[kv[0]] = aws.String(kv[1])
+	}
+	return l, nil
+}
+
+// SetLogConfiguration Sets the log configuration of the containers without one. The containers logging to
+// awslogs get the /ecs/<family> log group, the family as stream prefix and the region by default.
+func SetLogConfiguration(params *ecs.RegisterTaskDefinitionInput, l *ecs.LogConfiguration, region string) {
+	for _, c := range params.ContainerDefinitions {
+		if c.LogConfiguration == nil && l != nil {
+			c.LogConfiguration = &ecs.LogConfiguration{LogDriver: l.LogDriver, Options: map[string]*string{}}
+			for k, v := range l.Options {
+				c.LogConfiguration.Options[k] = v
+			}
+		}
+		if c.LogConfiguration == nil || aws.StringValue(c.LogConfiguration.LogDriver) != ecs.LogDriverAwslogs {
+			continue
+		}
+		defaults := map[string]string{
+			"awslogs-group":         "/ecs/" + aws.StringValue(params.Family),
+			"awslogs-stream-prefix": aws.StringValue(params.Family),
+			"awslogs-region":        region,
+		}
+		for k, v := range defaults {
+			if v == "" {
+				continue
+			}
+			if c.LogConfiguration.Options == nil {
+				c.LogConfiguration.Options = map[string]*string{}
+			}
+			if _, ok := c.LogConfiguration.Options[k]; !ok {
+				c.LogConfiguration.Options[k] = aws.String(v)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+func TestCliRegisterTaskLogs(t *testing.T) {
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}, Region: "eu-west-1"}
+	args := []string{"-family", "web", "-image", "example/web", "-log-driver", "awslogs", "-log-opt", "awslogs-group=web-logs",
+		"-container", "name=proxy,image=nginx,memory=64,log-driver=syslog,log-opt=tag=proxy"}
+	if _, err := commands["register"].Cmd(c, args); err != nil {
+		t.Fatal(err)
+	}
+	defs := svc.registered[0].ContainerDefinitions
+	app := defs[0].LogConfiguration
+	want := map[string]string{"awslogs-group": "web-logs", "awslogs-stream-prefix": "web", "awslogs-region": "eu-west-1"}
+	if *app.LogDriver != "awslogs" || !reflect.DeepEqual(aws.StringValueMap(app.Options), want) {
+		t.Errorf("app log configuration = %v, want awslogs with %v", app, want)
+	}
+	proxy := defs[1].LogConfiguration
+	if *proxy.LogDriver != "syslog" || !reflect.DeepEqual(aws.StringValueMap(proxy.Options), map[string]string{"tag": "proxy"}) {
+		t.Errorf("proxy log configuration = %v", proxy)
+	}
+
+	for _, args := range [][]string{
+		{"-family", "web", "-image", "example/web", "-log-driver", "papertrail"},
+		{"-family", "web", "-image", "example/web", "-log-opt", "tag=web"},
+		{"-family", "web", "-image", "example/web", "-log-driver", "gelf", "-log-opt", "gelf-address"},
+	} {
+		if _, err := commands["register"].Cmd(c, args); err == nil {
+			t.Errorf("register %v succeeded", args)
+		}
+	}
+}
+
+func TestCliRegisterTaskFileLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "taskdef.yaml")
+	def := `
+family: worker
+containerDefinitions:
+  - name: worker
+    image: example/worker
+    memory: 128
+    logConfiguration:
+      logDriver: awslogs
+  - name: metrics
+    image: statsd
+    memory: 64
+`
+	if err := ioutil.WriteFile(path, []byte(def), 0644); err != nil {
+		t.Fatal(err)
+	}
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}, Region: "us-west-2"}
+	if _, err := commands["register"].Cmd(c, []string{"-file", path, "-log-driver", "json-file", "-log-opt", "max-size=10m"}); err != nil {
+		t.Fatal(err)
+	}
+	defs := svc.registered[0].ContainerDefinitions
+	want := map[string]string{"awslogs-group": "/ecs/worker", "awslogs-stream-prefix": "worker", "awslogs-region": "us-west-2"}
+	if got := aws.StringValueMap(defs[0].LogConfiguration.Options); !reflect.DeepEqual(got, want) {
+		t.Errorf("worker log options = %v, want %v", got, want)
+	}
+	if l := defs[1].LogConfiguration; *l.LogDriver != "json-file" || *l.Options["max-size"] != "10m" {
+		t.Errorf("metrics log configuration = %v, want json-file", l)
+	}
+}
@@ -0,0 +1,273 @@
+package task
+
+import (
+	"errors"
+	"flag"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+)
+
+// InUse Returns the ARNs of the task definitions used by the running tasks and the services of the clusters,
+// including the ones of the deployments in progress. Every cluster is checked if clusters is empty.
+func InUse(svc ecsiface.ECSAPI, clusters []*string) (map[string]bool, error) {
+	if len(clusters) == 0 {
+		var err error
+		if clusters, err = listClusters(svc); err != nil {
+			return nil, err
+		}
+	}
+	used := map[string]bool{}
+	for _, cluster := range clusters {
+		tasks, err := listAllTasks(svc, cluster)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(tasks); i += 100 {
+			resp, err := svc.DescribeTasks(&ecs.DescribeTasksInput{Cluster: cluster, Tasks: tasks[i:minInt(i+100, len(tasks))]})
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range resp.Tasks {
+				used[aws.StringValue(t.TaskDefinitionArn)] = true
+			}
+		}
+		services, err := listAllServices(svc, cluster)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(services); i += 10 {
+			resp, err := svc.DescribeServices(&ecs.DescribeServicesInput{Cluster: cluster, Services: services[i:minInt(i+10, len(services))]})
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range resp.Services {
+				used[aws.StringValue(s.TaskDefinition)] = true
+				for _, d := range s.Deployments {
+					used[aws.StringValue(d.TaskDefinition)] = true
+				}
+			}
+		}
+	}
+	return used, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// listClusters Returns the ARNs of all the clusters
+func listClusters(svc ecsiface.ECSAPI) ([]*string, error) {
+	var ret []*string
+	params := &ecs.ListClustersInput{}
+	for {
+		resp, err := svc.ListClusters(params)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, resp.ClusterArns...)
+		if resp.NextToken == nil {
+			return ret, nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+// listAllTasks Returns the ARNs of the tasks of a cluster which should be running
+func listAllTasks(svc ecsiface.ECSAPI, cluster *string) ([]*string, error) {
+	var ret []*string
+	params := &ecs.ListTasksInput{Cluster: cluster, DesiredStatus: aws.String("RUNNING")}
+	for {
+		resp, err := svc.ListTasks(params)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, resp.TaskArns...)
+		if resp.NextToken == nil {
+			return ret, nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+// listAllServices Returns the ARNs of the services of a cluster
+func listAllServices(svc ecsiface.ECSAPI, cluster *string) ([]*string, error) {
+	var ret []*string
+	params := &ecs.ListServicesInput{Cluster: cluster}
+	for {
+		resp, err := svc.ListServices(params)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, resp.ServiceArns...)
+		if resp.NextToken == nil {
+			return ret, nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+// DeregisterTask Deregisters a task definition revision, unless a running task or a service of the clusters uses it.
+// Every cluster is checked if clusters is empty.
+func DeregisterTask(svc ecsiface.ECSAPI, taskDef *string, clusters []*string) (*ecs.DeregisterTaskDefinitionOutput, error) {
+	desc, err := svc.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: taskDef})
+	if err != nil {
+		return nil, err
+	}
+	arn := desc.TaskDefinition.TaskDefinitionArn
+	used, err := InUse(svc, clusters)
+	if err != nil {
+		return nil, err
+	}
+	if used[*arn] {
+		return nil, errors.New("The task definition " + *arn + " is used by a running task or a service")
+	}
+	return svc.DeregisterTaskDefinition(&ecs.DeregisterTaskDefinitionInput{TaskDefinition: arn})
+}
+
+// PruneOutput The ACTIVE task definition revisions of a family handled by Prune
+type PruneOutput struct {
+	// Deregistered The revisions deregistered, or to deregister in a dry run
+	Deregistered []string
+	// Kept The latest revisions
+	Kept []string
+	// InUse The older revisions used by a running task or a service
+	InUse  []string
+	DryRun bool
+}
+
+// Prune Deregisters the ACTIVE revisions of a family except the latest keep ones and the ones used by
+// a running task or a service of the clusters. Every cluster is checked if clusters is empty.
+// Nothing is deregistered in a dry run.
+func Prune(svc ecsiface.ECSAPI, family *string, keep int, clusters []*string, dryRun bool) (*PruneOutput, error) {
+	if aws.StringValue(family) == "" {
+		return nil, errors.New("The family can not be blank")
+	}
+	if keep < 0 {
+		return nil, errors.New("The number of kept revisions can not be negative")
+	}
+	var arns []string
+	params := &ecs.ListTaskDefinitionsInput{FamilyPrefix: family, Status: aws.String("ACTIVE")}
+	for {
+		resp, err := svc.ListTaskDefinitions(params)
+		if err != nil {
+			return nil, err
+		}
+		for _, arn := range aws.StringValueSlice(resp.TaskDefinitionArns) {
+			// The family prefix may match other families
+			if strings.HasSuffix(arn[:strings.LastIndex(arn, ":")], "/"+*family) {
+				arns = append(arns, arn)
+			}
+		}
+		if resp.NextToken == nil {
+			break
+		}
+		params.NextToken = resp.NextToken
+	}
+	sort.Slice(arns, func(i, j int) bool {
+		return revision(arns[i]) > revision(arns[j])
+	})
+	out := &PruneOutput{DryRun: dryRun}
+	if len(arns) <= keep {
+		out.Kept = arns
+		return out, nil
+	}
+	out.Kept = arns[:keep]
+	used, err := InUse(svc, clusters)
+	if err != nil {
+		return nil, err
+	}
+	for _, arn := range arns[keep:] {
+		if used[arn] {
+			out.InUse = append(out.InUse, arn)
+			continue
+		}
+		if !dryRun {
+			if _, err := svc.DeregisterTaskDefinition(&ecs.DeregisterTaskDefinitionInput{TaskDefinition: aws.String(arn)}); err != nil {
+				return out, err
+			}
+		}
+		out.Deregistered = append(out.Deregistered, arn)
+	}
+	return out, nil
+}
+
+// revision Returns the revision number of a task definition ARN
+func revision(arn string) int64 {
+	r, _ := strconv.ParseInt(arn[strings.LastIndex(arn, ":")+1:], 10, 64)
+	return r
+}
+
+// deregisterTaskOptions The parameters of the deregister command.
+// The clusters to check are not named cluster, so that the cluster of the configuration does not narrow the check.
+type deregisterTaskOptions struct {
+	taskDef  string
+	clusters cli.Strings
+}
+
+func (o *deregisterTaskOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	c.StringVar(&o.taskDef, "task-definition", "", "The family and revision (family:revision) or full Amazon Resource Name (ARN) of the task definition to deregister.")
+	c.Var(&o.clusters, "check-cluster", "A cluster whose running tasks and services are checked before deregistering. Can be repeated. Every cluster is checked by default.")
+	return c
+}
+
+func cliDeregisterTaskParams(args []string) *flag.FlagSet {
+	return new(deregisterTaskOptions).flags(args)
+}
+
+func cliDeregisterTask(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o deregisterTaskOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	if !strings.Contains(o.taskDef, ":") {
+		return nil, errors.New("The task-definition parameter must have a revision: family:revision or a full ARN")
+	}
+	resp, err := DeregisterTask(c.ECS, &o.taskDef, aws.StringSlice(o.clusters))
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, *resp.TaskDefinition.TaskDefinitionArn), nil
+}
+
+// pruneOptions The parameters of the prune command
+type pruneOptions struct {
+	family   string
+	keep     int
+	clusters cli.Strings
+	dryRun   bool
+}
+
+func (o *pruneOptions) flags(args []string) *flag.FlagSet {
+	var c = cli.Get("", args)
+	c.StringVar(&o.family, "family", "", "The family of the task definitions to prune.")
+	c.IntVar(&o.keep, "keep", 5, "The number of the latest ACTIVE revisions to keep.")
+	c.Var(&o.clusters, "check-cluster", "A cluster whose running tasks and services are checked before deregistering. Can be repeated. Every cluster is checked by default.")
+	c.BoolVar(&o.dryRun, "dry-run", false, "List the revisions to deregister without deregistering them.")
+	return c
+}
+
+func cliPruneParams(args []string) *flag.FlagSet {
+	return new(pruneOptions).flags(args)
+}
+
+func cliPrune(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o pruneOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	resp, err := Prune(c.ECS, &o.family, o.keep, aws.StringSlice(o.clusters), o.dryRun)
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, resp.Deregistered...), nil
+}
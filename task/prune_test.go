@@ -0,0 +1,122 @@
+package task
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gawkermedia/ecs/cli"
+	"github.com/gawkermedia/ecs/fake"
+)
+
+func taskDefinitionArn(family string, revision int) string {
+	return "arn:aws:ecs:us-east-1:" + fake.Account + ":task-definition/" + family + ":" + strconv.Itoa(revision)
+}
+
+// registerRevisions Registers revisions of the web and web-worker families in the fake backend, and starts revision 2 of web
+func registerRevisions(t *testing.T) *fake.Backend {
+	b := fake.New(fake.DefaultState(), "us-east-1")
+	for _, family := range []string{"web", "web", "web", "web", "web", "web", "web-worker"} {
+		params := &ecs.RegisterTaskDefinitionInput{
+			Family:               aws.String(family),
+			ContainerDefinitions: []*ecs.ContainerDefinition{{Name: aws.String("app"), Image: aws.String("example/web"), Memory: aws.Int64(128)}},
+		}
+		if _, err := RegisterTask(b, params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := StartTask(b, aws.String("web:2"), aws.StringSlice([]string{"00000000-0000-0000-0000-0000000000a1"}), aws.String("default"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestPrune(t *testing.T) {
+	b := registerRevisions(t)
+	c := b.Clients()
+
+	ret, err := commands["prune"].Cmd(c, []string{"-family", "web", "-keep", "2", "-dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PruneOutput{
+		Deregistered: []string{taskDefinitionArn("web", 4), taskDefinitionArn("web", 3), taskDefinitionArn("web", 1)},
+		Kept:         []string{taskDefinitionArn("web", 6), taskDefinitionArn("web", 5)},
+		InUse:        []string{taskDefinitionArn("web", 2)},
+		DryRun:       true,
+	}
+	if !reflect.DeepEqual(ret.Value, want) {
+		t.Errorf("dry run = %+v, want %+v", ret.Value, want)
+	}
+	if defs, _ := ListTaskDefs(b, aws.String("web"), aws.String("ACTIVE")); len(defs.TaskDefinitionArns) != 6 {
+		t.Errorf("%d ACTIVE revisions after a dry run, want 6", len(defs.TaskDefinitionArns))
+	}
+
+	out, err := Prune(b, aws.String("web"), 2, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.Deregistered, want.Deregistered) {
+		t.Errorf("deregistered %v, want %v", out.Deregistered, want.Deregistered)
+	}
+	defs, err := ListTaskDefs(b, nil, aws.String("ACTIVE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := []string{taskDefinitionArn("web", 2), taskDefinitionArn("web", 5), taskDefinitionArn("web", 6), taskDefinitionArn("web-worker", 1)}
+	if got := aws.StringValueSlice(defs.TaskDefinitionArns); !reflect.DeepEqual(got, active) {
+		t.Errorf("ACTIVE revisions after prune %v, want %v", got, active)
+	}
+}
+
+func TestDeregisterTask(t *testing.T) {
+	b := registerRevisions(t)
+	c := b.Clients()
+	if _, err := commands["deregister"].Cmd(c, []string{"-task-definition", "web:2"}); err == nil {
+		t.Error("deregister of a revision used by a running task succeeded")
+	}
+	if _, err := commands["deregister"].Cmd(c, []string{"-task-definition", "web"}); err == nil {
+		t.Error("deregister without revision succeeded")
+	}
+	ret, err := commands["deregister"].Cmd(c, []string{"-task-definition", "web:3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := ret.Value.(*ecs.DeregisterTaskDefinitionOutput); *out.TaskDefinition.Status != "INACTIVE" {
+		t.Errorf("deregistered task definition status = %s", *out.TaskDefinition.Status)
+	}
+}
+
+func TestPruneWithClusterDefault(t *testing.T) {
+	b := registerRevisions(t)
+	c := b.Clients()
+	if _, err := b.CreateCluster(&ecs.CreateClusterInput{ClusterName: aws.String("staging")}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := b.CreateService(&ecs.CreateServiceInput{ServiceName: aws.String("web"), TaskDefinition: aws.String("web:3"), DesiredCount: aws.Int64(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cluster of the environment does not restrict the clusters checked
+	defaults := &cli.Defaults{Flags: map[string]interface{}{"cluster": "staging"}}
+	args := defaults.Apply(cliPruneParams(nil), []string{"-family", "web", "-keep", "0", "-dry-run"})
+	ret, err := commands["prune"].Cmd(c, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inUse := []string{taskDefinitionArn("web", 3), taskDefinitionArn("web", 2)}
+	if got := ret.Value.(*PruneOutput).InUse; !reflect.DeepEqual(got, inUse) {
+		t.Errorf("revisions in use = %v, want %v", got, inUse)
+	}
+
+	ret, err = commands["prune"].Cmd(c, []string{"-family", "web", "-keep", "0", "-dry-run", "-check-cluster", "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ret.Value.(*PruneOutput).InUse; len(got) > 0 {
+		t.Errorf("revisions in use in the staging cluster = %v, want none", got)
+	}
+}
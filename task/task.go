@@ -3,6 +3,7 @@ package task
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -10,44 +11,23 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
 	"github.com/gawkermedia/ecs/cli"
-	"github.com/gawkermedia/ecs/sess"
 )
 
-var cliClusterName string
-var cliContainerInstance string
-var cliDesiredStatus string
-var cliStatus string
-var cliFamily string
-var cliMaxResults int64
-var cliMaxTries int
-var cliTimeout int64
-var cliServiceName string
-var cliTaskDef string
-var cliTasks string
-var cliStartedby string
-var cliContainerPort int64
-var cliHostPort int64
-var cliMountPath string
-var cliSourceVolume string
-var cliMountReadOnly bool
-var cliImage string
-var cliCPU int64
-var cliMemory int64
-var cliEssential bool
-var cliLinks string
-var cliWithConsul bool
-var cliConsulServerInstance string
-var cliTargetInstance string
+// clusterFlag Defines the cluster parameter shared by the commands
+func clusterFlag(c *flag.FlagSet, p *string) {
+	c.StringVar(p, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the tasks to list. If you do not specify a cluster, the default cluster is assumed..")
+}
 
 // describeEc2Instances Describes EC2 instances by container istance ID
-func describeEc2Instances(svc *ecs.ECS, cluster *string, containerInstances []*string) (*ec2.DescribeInstancesOutput, error) {
+func describeEc2Instances(c *cli.Clients, cluster *string, containerInstances []*string) (*ec2.DescribeInstancesOutput, error) {
 
 	params := &ecs.DescribeContainerInstancesInput{
 		Cluster:            cluster,
 		ContainerInstances: containerInstances,
 	}
-	ins, err := svc.DescribeContainerInstances(params)
+	ins, err := c.ECS.DescribeContainerInstances(params)
 	if err != nil {
 		return nil, err
 	}
@@ -59,16 +39,15 @@ func describeEc2Instances(svc *ecs.ECS, cluster *string, containerInstances []*s
 	for i, v := range ins.ContainerInstances {
 		ec2Instances[i] = v.Ec2InstanceId
 	}
-	ec2client := ec2.New(sess.InitSession())
 	ec2params := &ec2.DescribeInstancesInput{
 		DryRun:      aws.Bool(false),
 		InstanceIds: ec2Instances,
 	}
-	return ec2client.DescribeInstances(ec2params)
+	return c.EC2.DescribeInstances(ec2params)
 }
 
-func describeOneEc2Instance(svc *ecs.ECS, cluster *string, containerInstance *string) (*ec2.Instance, error) {
-	ins, err := describeEc2Instances(svc, cluster, []*string{containerInstance})
+func describeOneEc2Instance(c *cli.Clients, cluster *string, containerInstance *string) (*ec2.Instance, error) {
+	ins, err := describeEc2Instances(c, cluster, []*string{containerInstance})
 	if err != nil {
 		return nil, err
 	}
@@ -81,17 +60,7 @@ func describeOneEc2Instance(svc *ecs.ECS, cluster *string, containerInstance *st
 	return ins.Reservations[0].Instances[0], nil
 }
 
-func containerDef(family *string, containerPort *int64, hostPort *int64, image *string, cpu *int64, memory *int64, essential bool, links []*string) *ecs.ContainerDefinition {
-	portMapping := &ecs.PortMapping{
-		ContainerPort: containerPort,
-		HostPort:      hostPort,
-		Protocol:      aws.String("tcp"),
-	}
-	mountPoint := &ecs.MountPoint{
-		ContainerPath: aws.String("/var/www/" + (*family) + "-vol"),
-		SourceVolume:  aws.String(*family + "-vol"),
-		ReadOnly:      aws.Bool(false),
-	}
+func containerDef(family *string, ports []*ecs.PortMapping, image *string, cpu *int64, memory *int64, essential bool, links []*string) *ecs.ContainerDefinition {
 	c := ecs.ContainerDefinition{}
 
 	c.Name = aws.String(*family + "-app")
@@ -100,14 +69,11 @@ func containerDef(family *string, containerPort *int64, hostPort *int64, image *
 	c.Memory = memory
 	c.Essential = aws.Bool(essential)
 	c.Links = links
-	if *hostPort != 0 {
-		c.PortMappings = []*ecs.PortMapping{portMapping}
-	}
-	c.MountPoints = []*ecs.MountPoint{mountPoint}
+	c.PortMappings = ports
 	return &c
 }
 
-func consulDefinition(hostname *string, serverIP *string, advertiseIP *string) *ecs.ContainerDefinition {
+func consulDefinition(hostname *string, serverIP *string, advertiseIP *string, dc *string) *ecs.ContainerDefinition {
 	c := ecs.ContainerDefinition{}
 	c.Name = aws.String("kinja-consul-agent")
 	c.Hostname = hostname
@@ -160,13 +126,11 @@ func consulDefinition(hostname *string, serverIP *string, advertiseIP *string) *
 		},
 	}
 
-	session := sess.InitSession()
-
 	c.Command = []*string{
 		aws.String("--join " + *serverIP),
 		//aws.String("--advertise  $(curl -s http://169.254.169.254/latest/meta-data/local-ipv4)"),
 		aws.String("--advertise " + *advertiseIP),
-		aws.String("-dc " + *session.Config.Region),
+		aws.String("-dc " + *dc),
 		aws.String("--config-file /etc/consul/consul.json"),
 	}
 	return &c
@@ -196,144 +160,381 @@ func registratorDefinition(hostname *string, advertiseIP *string) *ecs.Container
 	return &c
 }
 
-// Definition Task definition
-func Definition(family *string, containerPort *int64, hostPort *int64, image *string, cpu *int64, memory *int64, essential bool, withConsul bool, links []*string) *ecs.RegisterTaskDefinitionInput {
-	size := 1
-	if withConsul {
-		size = 3
-	}
-	defs := make([]*ecs.ContainerDefinition, size)
-	defs[0] = containerDef(family, containerPort, hostPort, image, cpu, memory, essential, links)
-	return &ecs.RegisterTaskDefinitionInput{
-		ContainerDefinitions: defs,
-		Family:               family,
-		Volumes: []*ecs.Volume{
-			{
-				Name: aws.String(*family + "-vol"),
-				Host: &ecs.HostVolumeProperties{
-					SourcePath: aws.String("/tmp"),
-				},
-			},
-			{
-				Name: aws.String("consul-vol"),
-				Host: &ecs.HostVolumeProperties{
-					SourcePath: aws.String("/opt/consul"),
-				},
+// consulVolumes The host volumes mounted by the consul and registrator containers
+func consulVolumes() []*ecs.Volume {
+	return []*ecs.Volume{
+		{
+			Name: aws.String("consul-vol"),
+			Host: &ecs.HostVolumeProperties{
+				SourcePath: aws.String("/opt/consul"),
 			},
-			{
-				Name: aws.String("consul-socket"),
-				Host: &ecs.HostVolumeProperties{
-					SourcePath: aws.String("/var/run/docker.sock"),
-				},
+		},
+		{
+			Name: aws.String("consul-socket"),
+			Host: &ecs.HostVolumeProperties{
+				SourcePath: aws.String("/var/run/docker.sock"),
 			},
-			{
-				Name: aws.String("consul-config"),
-				Host: &ecs.HostVolumeProperties{
-					SourcePath: aws.String("/etc/consul"),
-				},
+		},
+		{
+			Name: aws.String("consul-config"),
+			Host: &ecs.HostVolumeProperties{
+				SourcePath: aws.String("/etc/consul"),
 			},
 		},
 	}
 }
 
+// Definition Task definition of the containers. Only the volumes mounted by a container are kept.
+func Definition(family *string, containers []*ecs.ContainerDefinition, volumes []*ecs.Volume) *ecs.RegisterTaskDefinitionInput {
+	mounted := map[string]bool{}
+	for _, c := range containers {
+		for _, m := range c.MountPoints {
+			mounted[aws.StringValue(m.SourceVolume)] = true
+		}
+	}
+	var used []*ecs.Volume
+	for _, v := range volumes {
+		if mounted[aws.StringValue(v.Name)] {
+			used = append(used, v)
+		}
+	}
+	return &ecs.RegisterTaskDefinitionInput{
+		ContainerDefinitions: containers,
+		Family:               family,
+		Volumes:              used,
+	}
+}
+
 // RegisterTask Register a new version of the task definition
-func RegisterTask(svc *ecs.ECS, params *ecs.RegisterTaskDefinitionInput) (*ecs.RegisterTaskDefinitionOutput, error) {
+func RegisterTask(svc ecsiface.ECSAPI, params *ecs.RegisterTaskDefinitionInput) (*ecs.RegisterTaskDefinitionOutput, error) {
 	return svc.RegisterTaskDefinition(params)
 }
 
-func cliRegisterTaskParams(args []string) *flag.FlagSet {
+// registerTaskOptions The parameters of the register command
+type registerTaskOptions struct {
+	cluster              string
+	file                 string
+	family               string
+	containerPort        int64
+	hostPort             int64
+	ports                cli.Strings
+	mountPath            string
+	sourceVolume         string
+	mountReadOnly        bool
+	volumes              cli.Strings
+	mounts               cli.Strings
+	image                string
+	cpu                  int64
+	memory               int64
+	essential            bool
+	links                string
+	env                  cli.Strings
+	envFile              string
+	command              string
+	entryPoint           string
+	workDir              string
+	logDriver            string
+	logOpts              cli.Strings
+	containers           cli.Strings
+	withConsul           bool
+	consulServerInstance string
+	targetInstance       string
+	skipLint             bool
+}
+
+func (o *registerTaskOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.Int64Var(&cliContainerPort, "container-port", 9000, "The port number on the container that is bound to the user-specified or automatically assigned host port. If you specify a container port and not a host port, your container will automatically receive a host port in the ephemeral port range (for more information, see hostPort)")
-	c.Int64Var(&cliHostPort, "host-port", 80, "The port number on the container instance to reserve for your container. You can specify a non-reserved host port for your container port mapping, or you can omit the hostPort (or set it to 0) while specifying a containerPort and your container will automatically receive a port in the ephemeral port range for your container instance operating system and Docker version.")
-	c.StringVar(&cliFamily, "family", "", "The name of the family with which to filter the list-tasks results. Specifying a family limits the results to tasks that belong to that family.")
-	c.StringVar(&cliMountPath, "mount-path", "", "The path on the container to mount the host volume at.")
-	c.StringVar(&cliSourceVolume, "source-volume", "", "The name of the volume to mount.")
-	c.BoolVar(&cliMountReadOnly, "mount-read-only", false, "If this value is true, the container has read-only access to the volume. If this value is false, then the container can write to the volume. The default value is false.")
-	c.StringVar(&cliImage, "image", "", "The image used to start a container. This string is passed directly to the Docker daemon. Images in the Docker Hub registry are available by default. Other repositories are specified with repository-url/image:tag.")
-	c.Int64Var(&cliCPU, "cpu", 512, "The number of cpu units reserved for the container. A container instance has 1,024 cpu units for every CPU core. This parameter specifies the minimum amount of CPU to reserve for a container, and containers share unallocated CPU units with other containers on the instance with the same ratio as their allocated amount.")
-	c.Int64Var(&cliMemory, "memory", 512, "The number of MiB of memory reserved for the container. If your container attempts to exceed the memory allocated here, the container is killed.")
-	c.BoolVar(&cliEssential, "essential", true, "If the essential parameter of a container is marked as true, the failure of that container will stop the task. If the essential parameter of a container is marked as false, then its failure will not affect the rest of the containers in a task. If this parameter is omitted, a container is assumed to be essential.")
-	c.StringVar(&cliLinks, "links", "", "A list of links for the container. Each link entry should be in the form of `container_name:alias.`")
-	c.BoolVar(&cliWithConsul, "with-consul", false, "Add consul and registrator to the container or not. Default `false`")
-	c.StringVar(&cliConsulServerInstance, "consul-server-instance", "", "The container instance id of the consul server.")
-	c.StringVar(&cliTargetInstance, "target-instance", "", "The target container instance id")
+	clusterFlag(c, &o.cluster)
+	c.StringVar(&o.file, "file", "", "A JSON or YAML file with the full task definition in the format of the RegisterTaskDefinition API call: family, containerDefinitions, volumes... ${NAME} variables are substituted, and a file ending in .tmpl is also rendered as a Go template. The container parameters are ignored, but -family overrides the family of the file.")
+	c.Int64Var(&o.containerPort, "container-port", 9000, "The port number on the container that is bound to the user-specified or automatically assigned host port. If you specify a container port and not a host port, your container will automatically receive a host port in the ephemeral port range (for more information, see hostPort). Set it to 0 for a container without port mapping.")
+	c.Int64Var(&o.hostPort, "host-port", 80, "The port number on the container instance to reserve for your container. You can specify a non-reserved host port for your container port mapping, or you can omit the hostPort (or set it to 0) while specifying a containerPort and your container will automatically receive a port in the ephemeral port range for your container instance operating system and Docker version.")
+	c.Var(&o.ports, "port", "A port mapping of the container in the form '[host:]container[/protocol]', e.g. 80:9000, 9000 or 8125:8125/udp. A missing or 0 host port is assigned from the ephemeral port range. Can be repeated. It overrides the container-port and host-port parameters.")
+	c.StringVar(&o.family, "family", "", "The name of the family with which to filter the list-tasks results. Specifying a family limits the results to tasks that belong to that family.")
+	c.StringVar(&o.mountPath, "mount-path", "", "The path on the container to mount the host volume at.")
+	c.StringVar(&o.sourceVolume, "source-volume", "", "The name of the volume to mount.")
+	c.BoolVar(&o.mountReadOnly, "mount-read-only", false, "If this value is true, the container has read-only access to the volume. If this value is false, then the container can write to the volume. The default value is false.")
+	c.Var(&o.volumes, "volume", "A volume of the task in the form 'name=host-path', or 'name' for a volume managed by Docker. Can be repeated. Only the volumes mounted by a container are registered.")
+	c.Var(&o.mounts, "mount", "A volume mounted in the container in the form 'volume:container-path[:ro]'. Can be repeated.")
+	c.StringVar(&o.image, "image", "", "The image used to start a container. This string is passed directly to the Docker daemon. Images in the Docker Hub registry are available by default. Other repositories are specified with repository-url/image:tag.")
+	c.Int64Var(&o.cpu, "cpu", 512, "The number of cpu units reserved for the container. A container instance has 1,024 cpu units for every CPU core. This parameter specifies the minimum amount of CPU to reserve for a container, and containers share unallocated CPU units with other containers on the instance with the same ratio as their allocated amount.")
+	c.Int64Var(&o.memory, "memory", 512, "The number of MiB of memory reserved for the container. If your container attempts to exceed the memory allocated here, the container is killed.")
+	c.BoolVar(&o.essential, "essential", true, "If the essential parameter of a container is marked as true, the failure of that container will stop the task. If the essential parameter of a container is marked as false, then its failure will not affect the rest of the containers in a task. If this parameter is omitted, a container is assumed to be essential.")
+	c.StringVar(&o.links, "links", "", "A list of links for the container. Each link entry should be in the form of `container_name:alias.`")
+	c.Var(&o.env, "env", "An environment variable of the container in the form 'KEY=VALUE'. Can be repeated. It overrides the variables of the env file.")
+	c.StringVar(&o.envFile, "env-file", "", "A file with the environment variables of the container, a KEY=VALUE line per variable. Empty lines and lines starting with # are skipped.")
+	c.StringVar(&o.command, "command", "", "The command of the container as a JSON array, e.g. '[\"sh\", \"-c\", \"exec app\"]', or as space separated words. It overrides the CMD of the image.")
+	c.StringVar(&o.entryPoint, "entrypoint", "", "The entry point of the container as a JSON array or as space separated words. It overrides the ENTRYPOINT of the image.")
+	c.StringVar(&o.workDir, "workdir", "", "The working directory of the command of the container.")
+	c.StringVar(&o.logDriver, "log-driver", "", "The Docker log driver of the containers without log configuration: "+strings.Join(ecs.LogDriver_Values(), ", ")+". The awslogs driver logs to the /ecs/<family> group of the region, with the family as stream prefix, unless the log options say otherwise.")
+	c.Var(&o.logOpts, "log-opt", "An option of the log driver in the form 'key=value', e.g. tag=web. Can be repeated.")
+	c.Var(&o.containers, "container", "An additional container in the form 'name=nginx,image=nginx:1.9,cpu=128,memory=256,port=80:8080,link=web-app:app,mount=volume:/path:ro,essential=false'. The port, link and mount fields can be repeated. A value with commas is quoted, e.g. env=\"HOSTS=a,b\", or is a JSON array, e.g. command=[\"sh\",\"-c\",\"exec app\"]. Can be repeated. If the image parameter is empty, only these containers are registered.")
+	c.BoolVar(&o.withConsul, "with-consul", false, "Add consul and registrator to the container or not. Default `false`")
+	c.StringVar(&o.consulServerInstance, "consul-server-instance", "", "The container instance id of the consul server.")
+	c.StringVar(&o.targetInstance, "target-instance", "", "The target container instance id")
+	c.BoolVar(&o.skipLint, "skip-lint", false, "Register the task definition without checking it, see the lint command.")
 	return c
 }
 
-func cliRegisterTask(svc *ecs.ECS, args []string) ([]*string, error) {
-	var links []*string
-	err := cliRegisterTaskParams(args).Parse(args)
-	if cliLinks != "" {
-		links = aws.StringSlice(strings.Split(cliLinks, ","))
-	}
-	params := Definition(
-		&cliFamily,
-		&cliContainerPort,
-		&cliHostPort,
-		&cliImage,
-		&cliCPU,
-		&cliMemory,
-		cliEssential,
-		cliWithConsul,
-		links)
-	if cliWithConsul {
-		ins, err := describeOneEc2Instance(svc, &cliClusterName, &cliTargetInstance)
+func cliRegisterTaskParams(args []string) *flag.FlagSet {
+	return new(registerTaskOptions).flags(args)
+}
+
+func cliRegisterTask(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o registerTaskOptions
+	f := o.flags(args)
+	if err := f.Parse(args); err != nil {
+		return nil, err
+	}
+	params, err := o.build(c)
+	if err != nil {
+		return nil, err
+	}
+	if !o.skipLint {
+		problems, err := o.lint(c, f, params)
+		if err != nil {
+			return nil, err
+		}
+		if err := lintError(problems); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := RegisterTask(c.ECS, params)
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewResult(resp, *resp.TaskDefinition.TaskDefinitionArn), nil
+}
+
+// build Returns the task definition of the file or of the container parameters, with the log configuration
+func (o *registerTaskOptions) build(c *cli.Clients) (*ecs.RegisterTaskDefinitionInput, error) {
+	var params *ecs.RegisterTaskDefinitionInput
+	var err error
+	if o.file != "" {
+		params, err = o.fileDefinition(c)
+	} else {
+		params, err = o.definition(c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := o.setLogConfiguration(c, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// definition Returns the task definition built from the container parameters
+func (o *registerTaskOptions) definition(c *cli.Clients) (*ecs.RegisterTaskDefinitionInput, error) {
+	var containers []*ecs.ContainerDefinition
+	if o.image != "" || len(o.containers) == 0 {
+		var links []*string
+		if o.links != "" {
+			links = aws.StringSlice(strings.Split(o.links, ","))
+		}
+		ports, err := o.portMappings()
+		if err != nil {
+			return nil, err
+		}
+		app := containerDef(&o.family, ports, &o.image, &o.cpu, &o.memory, o.essential, links)
+		if err := o.setCommand(app); err != nil {
+			return nil, err
+		}
+		if err := o.setMounts(app); err != nil {
+			return nil, err
+		}
+		containers = append(containers, app)
+	}
+	for _, spec := range o.containers {
+		container, err := ParseContainer(spec)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, container)
+	}
+	var volumes []*ecs.Volume
+	for _, spec := range o.volumes {
+		v, err := ParseVolume(spec)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, v)
+	}
+	if o.withConsul {
+		ins, err := describeOneEc2Instance(c, &o.cluster, &o.targetInstance)
 		if err != nil {
 			return nil, err
 		}
 		hostname := ins.InstanceId
 		advertise := ins.PublicIpAddress
-		consulIns, err := describeOneEc2Instance(svc, &cliClusterName, &cliConsulServerInstance)
+		consulIns, err := describeOneEc2Instance(c, &o.cluster, &o.consulServerInstance)
 		if err != nil {
 			return nil, err
 		}
 		consulIP := consulIns.PublicIpAddress
-		params.ContainerDefinitions[1] = consulDefinition(hostname, consulIP, advertise)
-		params.ContainerDefinitions[2] = registratorDefinition(hostname, consulIP)
+		containers = append(containers,
+			consulDefinition(hostname, consulIP, advertise, aws.String(c.Region)),
+			registratorDefinition(hostname, consulIP))
+		volumes = append(volumes, consulVolumes()...)
+	}
+	return Definition(&o.family, containers, volumes), nil
+}
+
+// portMappings Returns the port mappings of the app container
+func (o *registerTaskOptions) portMappings() ([]*ecs.PortMapping, error) {
+	if len(o.ports) == 0 {
+		if o.containerPort == 0 {
+			return nil, nil
+		}
+		return []*ecs.PortMapping{{
+			ContainerPort: &o.containerPort,
+			HostPort:      &o.hostPort,
+			Protocol:      aws.String("tcp"),
+		}}, nil
+	}
+	var ret []*ecs.PortMapping
+	for _, spec := range o.ports {
+		p, err := ParsePort(spec)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+// setCommand Sets the environment and the command parameters of the app container
+func (o *registerTaskOptions) setCommand(app *ecs.ContainerDefinition) error {
+	var env []string
+	if o.envFile != "" {
+		var err error
+		if env, err = LoadEnvFile(o.envFile); err != nil {
+			return err
+		}
 	}
-	resp, err := RegisterTask(svc, params)
+	environment, err := Environment(append(env, o.env...))
+	if err != nil {
+		return err
+	}
+	app.Environment = environment
+	if o.command != "" {
+		if app.Command, err = ParseCommand(o.command); err != nil {
+			return err
+		}
+	}
+	if o.entryPoint != "" {
+		if app.EntryPoint, err = ParseCommand(o.entryPoint); err != nil {
+			return err
+		}
+	}
+	app.WorkingDirectory = cli.String(o.workDir)
+	return nil
+}
+
+// setMounts Sets the mount points of the app container
+func (o *registerTaskOptions) setMounts(app *ecs.ContainerDefinition) error {
+	if o.mountPath != "" || o.sourceVolume != "" {
+		if o.mountPath == "" || o.sourceVolume == "" {
+			return errors.New("The mount-path and source-volume parameters must be set together")
+		}
+		app.MountPoints = append(app.MountPoints, &ecs.MountPoint{
+			ContainerPath: &o.mountPath,
+			SourceVolume:  &o.sourceVolume,
+			ReadOnly:      &o.mountReadOnly,
+		})
+	}
+	for _, spec := range o.mounts {
+		m, err := ParseMount(spec)
+		if err != nil {
+			return err
+		}
+		app.MountPoints = append(app.MountPoints, m)
+	}
+	return nil
+}
+
+// setLogConfiguration Sets the log configuration of the containers
+func (o *registerTaskOptions) setLogConfiguration(c *cli.Clients, params *ecs.RegisterTaskDefinitionInput) error {
+	var l *ecs.LogConfiguration
+	if o.logDriver != "" {
+		var err error
+		if l, err = LogConfiguration(o.logDriver, o.logOpts); err != nil {
+			return err
+		}
+	} else if len(o.logOpts) > 0 {
+		return errors.New("The log-opt parameter requires the log-driver parameter")
+	}
+	SetLogConfiguration(params, l, c.Region)
+	return nil
+}
+
+// fileDefinition Returns the task definition of the file given in the file parameter
+func (o *registerTaskOptions) fileDefinition(c *cli.Clients) (*ecs.RegisterTaskDefinitionInput, error) {
+	if o.withConsul {
+		return nil, errors.New("The -with-consul parameter can not be used with -file")
+	}
+	params, err := LoadDefinition(o.file, c.Vars)
 	if err != nil {
 		return nil, err
 	}
-	return []*string{resp.TaskDefinition.TaskDefinitionArn}, err
+	if o.family != "" {
+		params.Family = &o.family
+	}
+	return params, nil
 }
 
 // ListTasks Returns a list of tasks for a specified cluster.
-func ListTasks(svc *ecs.ECS, params *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
+func ListTasks(svc ecsiface.ECSAPI, params *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
 	return svc.ListTasks(params)
 }
 
-// Returns a `FlagSet` for `ListTasks`
-func cliListTasksParams(args []string) *flag.FlagSet {
+// listTasksOptions The parameters of the list command
+type listTasksOptions struct {
+	cluster           string
+	containerInstance string
+	desiredStatus     string
+	family            string
+	maxResults        int64
+	serviceName       string
+}
+
+func (o *listTasksOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.StringVar(&cliClusterName, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the tasks to list. If you do not specify a cluster, the default cluster is assumed..")
-	c.StringVar(&cliContainerInstance, "container-instance", "", "The container instance ID or full Amazon Resource Name (ARN) of the container instance with which to filter the list-tasks results. Specifying a containerInstance limits the results to tasks that belong to that container instance.")
-	c.StringVar(&cliDesiredStatus, "desired-status", "RUNNING", "The task status that you want to filter the `ListTasks` results with. Specifying a `desiredStatus` of STOPPED will limit the results to tasks that are in the STOPPED status, which can be useful for debugging tasks that are not starting properly or have died or finished. The default status filter is RUNNING.")
-	c.StringVar(&cliFamily, "family", "", "The name of the family with which to filter the list-tasks results. Specifying a family limits the results to tasks that belong to that family.")
-	c.Int64Var(&cliMaxResults, "max-items", 100, "The maximum number of task results returned by ListTasks in paginated output. When this parameter is used, ListTasks only returns maxResults results in a single page along with a nextToken response element. The remaining results of the initial request can be seen by sending another ListTasks request with the returned nextToken value. This value can be between 1 and 100. If this parameter is not used, then ListTasks returns up to 100 results and a nextToken value if applicable")
-	c.StringVar(&cliServiceName, "service-name", "", "The name of the service with which to filter the list-tasks results. Specifying a serviceName limits the results to tasks that belong to that service.")
+	clusterFlag(c, &o.cluster)
+	c.StringVar(&o.containerInstance, "container-instance", "", "The container instance ID or full Amazon Resource Name (ARN) of the container instance with which to filter the list-tasks results. Specifying a containerInstance limits the results to tasks that belong to that container instance.")
+	c.StringVar(&o.desiredStatus, "desired-status", "RUNNING", "The task status that you want to filter the `ListTasks` results with. Specifying a `desiredStatus` of STOPPED will limit the results to tasks that are in the STOPPED status, which can be useful for debugging tasks that are not starting properly or have died or finished. The default status filter is RUNNING.")
+	c.StringVar(&o.family, "family", "", "The name of the family with which to filter the list-tasks results. Specifying a family limits the results to tasks that belong to that family.")
+	c.Int64Var(&o.maxResults, "max-items", 100, "The maximum number of task results returned by ListTasks in paginated output. When this parameter is used, ListTasks only returns maxResults results in a single page along with a nextToken response element. The remaining results of the initial request can be seen by sending another ListTasks request with the returned nextToken value. This value can be between 1 and 100. If this parameter is not used, then ListTasks returns up to 100 results and a nextToken value if applicable")
+	c.StringVar(&o.serviceName, "service-name", "", "The name of the service with which to filter the list-tasks results. Specifying a serviceName limits the results to tasks that belong to that service.")
 	return c
 }
 
-func cliListTasks(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliListTasksParams(args).Parse(args)
+// Returns a `FlagSet` for `ListTasks`
+func cliListTasksParams(args []string) *flag.FlagSet {
+	return new(listTasksOptions).flags(args)
+}
+
+func cliListTasks(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o listTasksOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
 	params := &ecs.ListTasksInput{
-		Cluster:           &cliClusterName,
-		ContainerInstance: cli.String(cliContainerInstance),
-		DesiredStatus:     cli.String(cliDesiredStatus),
-		Family:            cli.String(cliFamily),
-		MaxResults:        &cliMaxResults,
-		ServiceName:       cli.String(cliServiceName),
-	}
-	resp, err := ListTasks(svc, params)
+		Cluster:           &o.cluster,
+		ContainerInstance: cli.String(o.containerInstance),
+		DesiredStatus:     cli.String(o.desiredStatus),
+		Family:            cli.String(o.family),
+		MaxResults:        &o.maxResults,
+		ServiceName:       cli.String(o.serviceName),
+	}
+	resp, err := ListTasks(c.ECS, params)
 	if err != nil {
 		return nil, err
 	}
-	return resp.TaskArns, err
+	return cli.NewResult(resp, aws.StringValueSlice(resp.TaskArns)...), nil
 }
 
 // ListTaskDefs Returns a list of task definitions that are registered to your account. You can filter the results by family name with the family parameter or by status with the status parameter.
-func ListTaskDefs(svc *ecs.ECS, familyPrefix *string, status *string) (*ecs.ListTaskDefinitionsOutput, error) {
+func ListTaskDefs(svc ecsiface.ECSAPI, familyPrefix *string, status *string) (*ecs.ListTaskDefinitionsOutput, error) {
 	params := &ecs.ListTaskDefinitionsInput{
 		FamilyPrefix: familyPrefix,
 		Status:       status,
@@ -345,24 +546,37 @@ func ListTaskDefs(svc *ecs.ECS, familyPrefix *string, status *string) (*ecs.List
 	return resp, nil
 }
 
-func cliListTaskDefsParams(args []string) *flag.FlagSet {
+// listTaskDefsOptions The parameters of the definitions command
+type listTaskDefsOptions struct {
+	family string
+	status string
+}
+
+func (o *listTaskDefsOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.StringVar(&cliFamily, "family", "", "The full family name with which to filter the list-task-definitions results. Specifying a family limits the listed task definitions to task definition revisions that belong to that family.")
-	c.StringVar(&cliStatus, "status", "ACTIVE", "The task definition status with which to filter the list-task-definitions results. By default, only ACTIVE task definitions are listed. By setting this parameter to INACTIVE , you can view task definitions that are INACTIVE as long as an active task or service still references them. Possible values: ACTIVE, INACTIVE")
+	c.StringVar(&o.family, "family", "", "The full family name with which to filter the list-task-definitions results. Specifying a family limits the listed task definitions to task definition revisions that belong to that family.")
+	c.StringVar(&o.status, "status", "ACTIVE", "The task definition status with which to filter the list-task-definitions results. By default, only ACTIVE task definitions are listed. By setting this parameter to INACTIVE , you can view task definitions that are INACTIVE as long as an active task or service still references them. Possible values: ACTIVE, INACTIVE")
 	return c
 }
 
-func cliListTaskDefs(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliListTaskDefsParams(args).Parse(args)
-	resp, err := ListTaskDefs(svc, cli.String(cliFamily), cli.String(cliStatus))
+func cliListTaskDefsParams(args []string) *flag.FlagSet {
+	return new(listTaskDefsOptions).flags(args)
+}
+
+func cliListTaskDefs(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o listTaskDefsOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	resp, err := ListTaskDefs(c.ECS, cli.String(o.family), cli.String(o.status))
 	if err != nil {
 		return nil, err
 	}
-	return resp.TaskDefinitionArns, nil
+	return cli.NewResult(resp, aws.StringValueSlice(resp.TaskDefinitionArns)...), nil
 }
 
 // DescribeTasks Describes an ECS task
-func DescribeTasks(svc *ecs.ECS, taskArns []*string, cluster *string) (*ecs.DescribeTasksOutput, error) {
+func DescribeTasks(svc ecsiface.ECSAPI, taskArns []*string, cluster *string) (*ecs.DescribeTasksOutput, error) {
 	if len(taskArns) == 0 {
 		return nil, errors.New("Tasks can not be blank")
 	}
@@ -377,19 +591,32 @@ func DescribeTasks(svc *ecs.ECS, taskArns []*string, cluster *string) (*ecs.Desc
 	return resp, nil
 }
 
-func cliDescribeTasksParams(args []string) *flag.FlagSet {
+// describeTasksOptions The parameters of the desc command
+type describeTasksOptions struct {
+	cluster string
+	tasks   string
+}
+
+func (o *describeTasksOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.StringVar(&cliClusterName, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the tasks to list. If you do not specify a cluster, the default cluster is assumed..")
-	c.StringVar(&cliTasks, "tasks", "", "Comma separated list of taskarns.")
+	clusterFlag(c, &o.cluster)
+	c.StringVar(&o.tasks, "tasks", "", "Comma separated list of taskarns.")
 	return c
 }
 
-func cliDescribeTasks(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliDescribeTasksParams(args).Parse(args)
+func cliDescribeTasksParams(args []string) *flag.FlagSet {
+	return new(describeTasksOptions).flags(args)
+}
+
+func cliDescribeTasks(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o describeTasksOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
 	resp, err := DescribeTasks(
-		svc,
-		aws.StringSlice(strings.Split(cliTasks, ",")),
-		&cliClusterName,
+		c.ECS,
+		aws.StringSlice(strings.Split(o.tasks, ",")),
+		&o.cluster,
 	)
 	if err != nil {
 		return nil, err
@@ -398,15 +625,40 @@ func cliDescribeTasks(svc *ecs.ECS, args []string) ([]*string, error) {
 	if fail != nil {
 		return nil, fail
 	}
-	var ret = make([]*string, len(resp.Tasks))
-	for k := range resp.Tasks {
-		ret[k] = resp.Tasks[k].TaskArn
+	return cli.NewResult(resp, taskArns(resp.Tasks)...), nil
+}
+
+// taskArns Returns the ARNs of the tasks
+func taskArns(tasks []*ecs.Task) []string {
+	var ret = make([]string, len(tasks))
+	for k := range tasks {
+		ret[k] = aws.StringValue(tasks[k].TaskArn)
+	}
+	return ret
+}
+
+// bindingLines Returns a line per network binding of the tasks with the task ARN,
+// the container name and the ports, e.g. 0.0.0.0:32768->9000/tcp
+func bindingLines(tasks []*ecs.Task) []string {
+	var ret []string
+	for _, t := range tasks {
+		for _, c := range t.Containers {
+			for _, nb := range c.NetworkBindings {
+				ret = append(ret, fmt.Sprintf("%s %s %s:%d->%d/%s",
+					aws.StringValue(t.TaskArn),
+					aws.StringValue(c.Name),
+					aws.StringValue(nb.BindIP),
+					aws.Int64Value(nb.HostPort),
+					aws.Int64Value(nb.ContainerPort),
+					aws.StringValue(nb.Protocol)))
+			}
+		}
 	}
-	return ret, err
+	return ret
 }
 
 // StartTask Starts a new task in ECS
-func StartTask(svc *ecs.ECS, taskDef *string, containerInstances []*string, cluster *string, startedBy *string, overrides *ecs.TaskOverride) (*ecs.StartTaskOutput, error) {
+func StartTask(svc ecsiface.ECSAPI, taskDef *string, containerInstances []*string, cluster *string, startedBy *string, overrides *ecs.TaskOverride) (*ecs.StartTaskOutput, error) {
 	params := &ecs.StartTaskInput{
 		Cluster:            cluster,
 		ContainerInstances: containerInstances,
@@ -421,17 +673,22 @@ func StartTask(svc *ecs.ECS, taskDef *string, containerInstances []*string, clus
 	return resp, err
 }
 
+// StartWaitOutput The tasks started by StartWait and the EC2 instances they run on
+type StartWaitOutput struct {
+	Tasks     []*ecs.Task
+	Instances []*ec2.Instance
+}
+
 // StartWait Starts a new task a waits until it started successfully.
-func StartWait(svc *ecs.ECS, maxTries *int, timeout *int64, taskDef *string, containerInstances []*string, cluster *string, startedBy *string, overrides *ecs.TaskOverride) (*ecs.DescribeTasksOutput, error) {
+func StartWait(svc ecsiface.ECSAPI, maxTries *int, timeout *int64, taskDef *string, containerInstances []*string, cluster *string, startedBy *string, overrides *ecs.TaskOverride) (*ecs.DescribeTasksOutput, error) {
 	tries := 0
-	counter := 0
 	start, err := StartTask(
 		svc,
 		taskDef,
 		containerInstances,
 		cluster,
 		startedBy,
-		nil,
+		overrides,
 	)
 	if err != nil {
 		return nil, err
@@ -448,18 +705,22 @@ func StartWait(svc *ecs.ECS, maxTries *int, timeout *int64, taskDef *string, con
 		resp, err := DescribeTasks(
 			svc,
 			tasks,
-			&cliClusterName,
+			cluster,
 		)
+		if err != nil {
+			return nil, err
+		}
 		descFail := cli.Failure(resp.Failures, err)
 		if descFail != nil {
 			return nil, descFail
 		}
+		counter := 0
 		for _, v := range resp.Tasks {
 			if *v.LastStatus == *v.DesiredStatus {
 				counter = counter + 1
 			}
-			tries = tries + 1
 		}
+		tries = tries + 1
 		if counter >= len(resp.Tasks) {
 			return resp, nil
 		}
@@ -470,28 +731,38 @@ func StartWait(svc *ecs.ECS, maxTries *int, timeout *int64, taskDef *string, con
 	}
 }
 
-func cliStartWaitParams(args []string) *flag.FlagSet {
-	var c = cli.Get("", args)
-	c.Int64Var(&cliTimeout, "timeout", 2, "Wait seconds between two taks polling.")
-	c.IntVar(&cliMaxTries, "max-tries", 10, "Max attempts to find a started task.")
-	c.StringVar(&cliClusterName, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the tasks to list. If you do not specify a cluster, the default cluster is assumed..")
-	c.StringVar(&cliContainerInstance, "container-instances", "", "Comma separated list of container instance IDs or full Amazon Resource Name (ARN) entries for the container instances on which you would like to place your task. The list of container instances to start tasks on is limited to 10.")
-	c.StringVar(&cliTaskDef, "task-definition", "", "The family and revision (family:revision ) or full Amazon Resource Name (ARN) of the task definition to start. If a revision is not specified, the latest ACTIVE revision is used.")
-	c.StringVar(&cliStartedby, "started-by", "", "An optional tag specified when a task is started. For example if you automatically trigger a task to run a batch process job, you could apply a unique identifier for that job to your task with the startedBy parameter. You can then identify which tasks belong to that job by filtering the results of a list-tasks call with the startedBy value. If a task is started by an Amazon ECS service, then the startedBy parameter contains the deployment ID of the service that starts it.")
+// startWaitOptions The parameters of the start-wait command
+type startWaitOptions struct {
+	startTaskOptions
+	timeout  int64
+	maxTries int
+}
+
+func (o *startWaitOptions) flags(args []string) *flag.FlagSet {
+	var c = o.startTaskOptions.flags(args)
+	c.Int64Var(&o.timeout, "timeout", 2, "Wait seconds between two taks polling.")
+	c.IntVar(&o.maxTries, "max-tries", 10, "Max attempts to find a started task.")
 	return c
 }
 
-func cliStartWait(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliStartWaitParams(args).Parse(args)
-	containerInstances := aws.StringSlice(strings.Split(cliContainerInstance, ","))
+func cliStartWaitParams(args []string) *flag.FlagSet {
+	return new(startWaitOptions).flags(args)
+}
+
+func cliStartWait(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o startWaitOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
+	containerInstances := aws.StringSlice(strings.Split(o.containerInstances, ","))
 	resp, err := StartWait(
-		svc,
-		&cliMaxTries,
-		&cliTimeout,
-		&cliTaskDef,
+		c.ECS,
+		&o.maxTries,
+		&o.timeout,
+		&o.taskDef,
 		containerInstances,
-		cli.String(cliClusterName),
-		cli.String(cliStartedby),
+		cli.String(o.cluster),
+		cli.String(o.startedBy),
 		nil,
 	)
 	if err != nil {
@@ -501,41 +772,53 @@ func cliStartWait(svc *ecs.ECS, args []string) ([]*string, error) {
 	if fail != nil {
 		return nil, fail
 	}
-	var ret = make([]*string, len(resp.Tasks))
-	for k := range resp.Tasks {
-		ret[k] = resp.Tasks[k].TaskArn
-	}
-	dns, dnserr := describeEc2Instances(svc, cli.String(cliClusterName), containerInstances)
+	ret := &StartWaitOutput{Tasks: resp.Tasks}
+	lines := append(taskArns(resp.Tasks), bindingLines(resp.Tasks)...)
+	dns, dnserr := describeEc2Instances(c, cli.String(o.cluster), containerInstances)
 	if dnserr != nil {
-		return ret, dnserr
+		return cli.NewResult(ret, lines...), dnserr
 	}
-	var result = make([]*string, len(ret)+len(dns.Reservations))
-	copy(result, ret)
 	for _, r := range dns.Reservations {
-		for i, v := range r.Instances {
-			result[len(ret)+i] = v.PublicDnsName
+		for _, v := range r.Instances {
+			ret.Instances = append(ret.Instances, v)
+			lines = append(lines, aws.StringValue(v.PublicDnsName))
 		}
 	}
-	return result, nil
+	return cli.NewResult(ret, lines...), nil
 }
 
-func cliStartTaskParams(args []string) *flag.FlagSet {
+// startTaskOptions The parameters of the start command
+type startTaskOptions struct {
+	cluster            string
+	containerInstances string
+	taskDef            string
+	startedBy          string
+}
+
+func (o *startTaskOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.StringVar(&cliClusterName, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the tasks to list. If you do not specify a cluster, the default cluster is assumed..")
-	c.StringVar(&cliContainerInstance, "container-instances", "", "Comma separated list of container instance IDs or full Amazon Resource Name (ARN) entries for the container instances on which you would like to place your task. The list of container instances to start tasks on is limited to 10.")
-	c.StringVar(&cliTaskDef, "task-definition", "", "The family and revision (family:revision ) or full Amazon Resource Name (ARN) of the task definition to start. If a revision is not specified, the latest ACTIVE revision is used.")
-	c.StringVar(&cliStartedby, "started-by", "", "An optional tag specified when a task is started. For example if you automatically trigger a task to run a batch process job, you could apply a unique identifier for that job to your task with the startedBy parameter. You can then identify which tasks belong to that job by filtering the results of a list-tasks call with the startedBy value. If a task is started by an Amazon ECS service, then the startedBy parameter contains the deployment ID of the service that starts it.")
+	clusterFlag(c, &o.cluster)
+	c.StringVar(&o.containerInstances, "container-instances", "", "Comma separated list of container instance IDs or full Amazon Resource Name (ARN) entries for the container instances on which you would like to place your task. The list of container instances to start tasks on is limited to 10.")
+	c.StringVar(&o.taskDef, "task-definition", "", "The family and revision (family:revision ) or full Amazon Resource Name (ARN) of the task definition to start. If a revision is not specified, the latest ACTIVE revision is used.")
+	c.StringVar(&o.startedBy, "started-by", "", "An optional tag specified when a task is started. For example if you automatically trigger a task to run a batch process job, you could apply a unique identifier for that job to your task with the startedBy parameter. You can then identify which tasks belong to that job by filtering the results of a list-tasks call with the startedBy value. If a task is started by an Amazon ECS service, then the startedBy parameter contains the deployment ID of the service that starts it.")
 	return c
 }
 
-func cliStartTask(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliStartTaskParams(args).Parse(args)
+func cliStartTaskParams(args []string) *flag.FlagSet {
+	return new(startTaskOptions).flags(args)
+}
+
+func cliStartTask(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o startTaskOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
 	resp, err := StartTask(
-		svc,
-		&cliTaskDef,
-		aws.StringSlice(strings.Split(cliContainerInstance, ",")),
-		cli.String(cliClusterName),
-		cli.String(cliStartedby),
+		c.ECS,
+		&o.taskDef,
+		aws.StringSlice(strings.Split(o.containerInstances, ",")),
+		cli.String(o.cluster),
+		cli.String(o.startedBy),
 		nil,
 	)
 	if err != nil {
@@ -545,15 +828,12 @@ func cliStartTask(svc *ecs.ECS, args []string) ([]*string, error) {
 	if fail != nil {
 		return nil, fail
 	}
-	var ret = make([]*string, len(resp.Tasks))
-	for k := range resp.Tasks {
-		ret[k] = resp.Tasks[k].TaskArn
-	}
-	return ret, nil
+	// The tasks are not running yet, so their network bindings are not known: start-wait reports them
+	return cli.NewResult(resp, taskArns(resp.Tasks)...), nil
 }
 
 // StopTask Stops a task
-func StopTask(svc *ecs.ECS, task *string, cluster *string) (*ecs.StopTaskOutput, error) {
+func StopTask(svc ecsiface.ECSAPI, task *string, cluster *string) (*ecs.StopTaskOutput, error) {
 	params := &ecs.StopTaskInput{
 		Task:    task,
 		Cluster: cluster,
@@ -565,65 +845,173 @@ func StopTask(svc *ecs.ECS, task *string, cluster *string) (*ecs.StopTaskOutput,
 	return resp, nil
 }
 
-func cliStopTaskParams(args []string) *flag.FlagSet {
+// stopTaskOptions The parameters of the stop command
+type stopTaskOptions struct {
+	cluster string
+	task    string
+}
+
+func (o *stopTaskOptions) flags(args []string) *flag.FlagSet {
 	var c = cli.Get("", args)
-	c.StringVar(&cliClusterName, "cluster", "default", "The short name or full Amazon Resource Name (ARN) of the cluster that hosts the tasks to list. If you do not specify a cluster, the default cluster is assumed..")
-	c.StringVar(&cliTaskDef, "task", "", "The family and revision (family:revision ) or full Amazon Resource Name (ARN) of the task definition to start. If a revision is not specified, the latest ACTIVE revision is used.")
+	clusterFlag(c, &o.cluster)
+	c.StringVar(&o.task, "task", "", "The family and revision (family:revision ) or full Amazon Resource Name (ARN) of the task definition to start. If a revision is not specified, the latest ACTIVE revision is used.")
 	return c
 }
 
-func cliStopTask(svc *ecs.ECS, args []string) ([]*string, error) {
-	err := cliStopTaskParams(args).Parse(args)
+func cliStopTaskParams(args []string) *flag.FlagSet {
+	return new(stopTaskOptions).flags(args)
+}
+
+func cliStopTask(c *cli.Clients, args []string) (*cli.Result, error) {
+	var o stopTaskOptions
+	if err := o.flags(args).Parse(args); err != nil {
+		return nil, err
+	}
 	resp, err := StopTask(
-		svc,
-		&cliTaskDef,
-		cli.String(cliClusterName),
+		c.ECS,
+		&o.task,
+		cli.String(o.cluster),
 	)
 	if err != nil {
 		return nil, err
 	}
-	return []*string{resp.Task.TaskArn, resp.Task.DesiredStatus, resp.Task.LastStatus}, nil
+	return cli.NewResult(resp, *resp.Task.TaskArn, *resp.Task.DesiredStatus, *resp.Task.LastStatus), nil
+}
+
+// The columns of the table output of tasks
+var taskColumns = []cli.Column{
+	{Header: "TASK ARN", Path: "TaskArn"},
+	{Header: "LAST STATUS", Path: "LastStatus"},
+	{Header: "DESIRED STATUS", Path: "DesiredStatus"},
+	{Header: "CONTAINER INSTANCE", Path: "ContainerInstanceArn"},
+	{Header: "STARTED BY", Path: "StartedBy"},
+	{Header: "HOST PORTS", Path: "Containers[].NetworkBindings[].HostPort"},
+}
+
+var tasksTable = &cli.Table{Rows: "Tasks", Columns: taskColumns}
+
+var stoppedTaskTable = &cli.Table{Rows: "Task", Columns: taskColumns}
+
+// The table output of a task definition
+var taskDefinitionTable = &cli.Table{
+	Rows: "TaskDefinition",
+	Columns: []cli.Column{
+		{Header: "FAMILY", Path: "Family"},
+		{Header: "REVISION", Path: "Revision"},
+		{Header: "STATUS", Path: "Status"},
+		{Header: "TASK DEFINITION ARN", Path: "TaskDefinitionArn"},
+	},
 }
 
 var commands = map[string]cli.Command{
 	"desc": {
-		cliDescribeTasks,
-		"Describes a specified task or tasks.",
-		cliDescribeTasksParams,
+		Cmd:   cliDescribeTasks,
+		Desc:  "Describes a specified task or tasks.",
+		Help:  cliDescribeTasksParams,
+		Table: tasksTable,
 	},
 	"list": {
-		cliListTasks,
-		"Returns a list of tasks for a specified cluster. You can filter the results by family name, by a particular container instance, or by the desired status of the task with the family , containerInstance , and desiredStatus parameters.",
-		cliListTasksParams,
+		Cmd:  cliListTasks,
+		Desc: "Returns a list of tasks for a specified cluster. You can filter the results by family name, by a particular container instance, or by the desired status of the task with the family , containerInstance , and desiredStatus parameters.",
+		Help: cliListTasksParams,
+		Table: &cli.Table{
+			Rows:    "TaskArns",
+			Columns: []cli.Column{{Header: "TASK ARN"}},
+		},
 	},
 	"definitions": {
-		cliListTaskDefs,
-		"Returns a list of task definitions that are registered to your account. You can filter the results by family name with the family parameter or by status with the status parameter.",
-		cliListTaskDefsParams,
+		Cmd:  cliListTaskDefs,
+		Desc: "Returns a list of task definitions that are registered to your account. You can filter the results by family name with the family parameter or by status with the status parameter.",
+		Help: cliListTaskDefsParams,
+		Table: &cli.Table{
+			Rows:    "TaskDefinitionArns",
+			Columns: []cli.Column{{Header: "TASK DEFINITION ARN"}},
+		},
+	},
+	"definition-desc": {
+		Cmd:  cliDescribeTaskDef,
+		Desc: "Describes a task definition with its containers, ports, links, mounts, environment and command. It can be exported to a file registrable with register -file.",
+		Help: cliDescribeTaskDefParams,
+		Table: &cli.Table{
+			Rows: "TaskDefinition.ContainerDefinitions",
+			Columns: []cli.Column{
+				{Header: "NAME", Path: "Name"},
+				{Header: "IMAGE", Path: "Image"},
+				{Header: "CPU", Path: "Cpu"},
+				{Header: "MEMORY", Path: "Memory"},
+				{Header: "ESSENTIAL", Path: "Essential"},
+				{Header: "PORTS", Path: "PortMappings[].ContainerPort"},
+				{Header: "LINKS", Path: "Links"},
+			},
+		},
+	},
+	"lint": {
+		Cmd:  cliLintTask,
+		Desc: "Checks a task definition built from the register parameters: memory, links, mounts, host ports, essential containers and, if the cluster parameter is set, the capacity of its container instances. It fails if a problem is found.",
+		Help: cliLintTaskParams,
+		Table: &cli.Table{
+			Rows: "@",
+			Columns: []cli.Column{
+				{Header: "FIELD", Path: "Path"},
+				{Header: "PROBLEM", Path: "Message"},
+			},
+		},
 	},
 	"register": {
-		cliRegisterTask,
-		"Registers a new task definition from the supplied family and containerDefinitions.",
-		cliRegisterTaskParams,
+		Cmd:   cliRegisterTask,
+		Desc:  "Registers a new task definition from the supplied family and containerDefinitions.",
+		Help:  cliRegisterTaskParams,
+		Table: taskDefinitionTable,
+	},
+	"deregister": {
+		Cmd:   cliDeregisterTask,
+		Desc:  "Deregisters a task definition revision, unless a running task or a service uses it.",
+		Help:  cliDeregisterTaskParams,
+		Table: taskDefinitionTable,
+	},
+	"prune": {
+		Cmd:  cliPrune,
+		Desc: "Deregisters the old ACTIVE revisions of a task definition family, except the ones used by a running task or a service.",
+		Help: cliPruneParams,
+		Table: &cli.Table{
+			Rows:    "Deregistered",
+			Columns: []cli.Column{{Header: "DEREGISTERED TASK DEFINITION ARN"}},
+		},
+	},
+	"diff": {
+		Cmd:  cliDiffTasks,
+		Desc: "Compares two task definition revisions field by field: containers, images, environment, ports, mounts, cpu, memory...",
+		Help: cliDiffTasksParams,
+		Table: &cli.Table{
+			Rows: "Changes",
+			Columns: []cli.Column{
+				{Header: "FIELD", Path: "Path"},
+				{Header: "OLD", Path: "Old"},
+				{Header: "NEW", Path: "New"},
+			},
+		},
 	},
 	"start": {
-		cliStartTask,
-		"Starts a new task from the specified task definition on the specified container instance or instances. To use the default Amazon ECS scheduler to place your task, use run-task instead.",
-		cliStartTaskParams,
+		Cmd:   cliStartTask,
+		Desc:  "Starts a new task from the specified task definition on the specified container instance or instances. To use the default Amazon ECS scheduler to place your task, use run-task instead. Use start-wait to learn the host ports assigned to the task.",
+		Help:  cliStartTaskParams,
+		Table: tasksTable,
 	},
 	"start-wait": {
-		cliStartWait,
-		"Starts a new task from the specified task definition on the specified container instance or instances. It's blocks until the specified task starts and print its data.",
-		cliStartWaitParams,
+		Cmd:   cliStartWait,
+		Desc:  "Starts a new task from the specified task definition on the specified container instance or instances. It's blocks until the specified task starts and print its data, with the host ports of its network bindings.",
+		Help:  cliStartWaitParams,
+		Table: tasksTable,
 	},
 	"stop": {
-		cliStopTask,
-		"Stops a running task.",
-		cliStopTaskParams,
+		Cmd:   cliStopTask,
+		Desc:  "Stops a running task.",
+		Help:  cliStopTaskParams,
+		Table: stoppedTaskTable,
 	},
 }
 
 // Run Main entry point, which runs a command or display a help message.
-func Run(command string, args []string) ([]*string, error) {
-	return cli.Run(command, commands, args)
+func Run(c *cli.Clients, o *cli.Options, command string, args []string) error {
+	return cli.Run(c, o, command, commands, args)
 }
@@ -0,0 +1,397 @@
+package task
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/gawkermedia/ecs/cli"
+	"github.com/gawkermedia/ecs/fake"
+)
+
+// fakeECS Keeps the tasks and the task definitions in memory.
+// A started task is PENDING until it is described pendingPolls times.
+type fakeECS struct {
+	ecsiface.ECSAPI
+	pendingPolls int
+	polls        map[string]int
+	tasks        map[string]*ecs.Task
+	registered   []*ecs.RegisterTaskDefinitionInput
+	started      []*ecs.StartTaskInput
+	described    []*ecs.DescribeTasksInput
+	instances    map[string]string
+}
+
+func newFakeECS(pendingPolls int) *fakeECS {
+	return &fakeECS{
+		pendingPolls: pendingPolls,
+		polls:        map[string]int{},
+		tasks:        map[string]*ecs.Task{},
+		instances: map[string]string{
+			"ci-1": "i-1",
+			"ci-2": "i-2",
+		},
+	}
+}
+
+func (f *fakeECS) RegisterTaskDefinition(in *ecs.RegisterTaskDefinitionInput) (*ecs.RegisterTaskDefinitionOutput, error) {
+	f.registered = append(f.registered, in)
+	revision := int64(len(f.registered))
+	return &ecs.RegisterTaskDefinitionOutput{
+		TaskDefinition: &ecs.TaskDefinition{
+			Family:               in.Family,
+			Revision:             aws.Int64(revision),
+			Status:               aws.String("ACTIVE"),
+			TaskDefinitionArn:    aws.String("arn:aws:ecs:us-east-1:123456789012:task-definition/" + *in.Family + ":" + strconv.FormatInt(revision, 10)),
+			ContainerDefinitions: in.ContainerDefinitions,
+			Volumes:              in.Volumes,
+		},
+	}, nil
+}
+
+func (f *fakeECS) StartTask(in *ecs.StartTaskInput) (*ecs.StartTaskOutput, error) {
+	f.started = append(f.started, in)
+	out := &ecs.StartTaskOutput{}
+	for _, ci := range in.ContainerInstances {
+		if _, ok := f.instances[*ci]; !ok {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: ci, Reason: aws.String("MISSING")})
+			continue
+		}
+		arn := "arn:aws:ecs:us-east-1:123456789012:task/" + strconv.Itoa(len(f.tasks)+1)
+		t := &ecs.Task{
+			TaskArn:              aws.String(arn),
+			TaskDefinitionArn:    in.TaskDefinition,
+			ContainerInstanceArn: ci,
+			StartedBy:            in.StartedBy,
+			LastStatus:           aws.String("PENDING"),
+			DesiredStatus:        aws.String("RUNNING"),
+		}
+		f.tasks[arn] = t
+		out.Tasks = append(out.Tasks, t)
+	}
+	return out, nil
+}
+
+func (f *fakeECS) DescribeTasks(in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	f.described = append(f.described, in)
+	out := &ecs.DescribeTasksOutput{}
+	for _, arn := range in.Tasks {
+		t, ok := f.tasks[*arn]
+		if !ok {
+			out.Failures = append(out.Failures, &ecs.Failure{Arn: arn, Reason: aws.String("MISSING")})
+			continue
+		}
+		f.polls[*arn]++
+		if *t.LastStatus == "PENDING" && f.pendingPolls >= 0 && f.polls[*arn] > f.pendingPolls {
+			t.LastStatus = aws.String("RUNNING")
+		}
+		out.Tasks = append(out.Tasks, t)
+	}
+	return out, nil
+}
+
+func (f *fakeECS) StopTask(in *ecs.StopTaskInput) (*ecs.StopTaskOutput, error) {
+	t, ok := f.tasks[*in.Task]
+	if !ok {
+		return nil, errors.New("InvalidParameterException: The referenced task was not found.")
+	}
+	t.DesiredStatus = aws.String("STOPPED")
+	t.LastStatus = aws.String("STOPPED")
+	return &ecs.StopTaskOutput{Task: t}, nil
+}
+
+func (f *fakeECS) DescribeContainerInstances(in *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	out := &ecs.DescribeContainerInstancesOutput{}
+	for _, ci := range in.ContainerInstances {
+		out.ContainerInstances = append(out.ContainerInstances, &ecs.ContainerInstance{
+			ContainerInstanceArn: ci,
+			Ec2InstanceId:        aws.String(f.instances[*ci]),
+		})
+	}
+	return out, nil
+}
+
+// fakeEC2 Describes an instance per requested ID
+type fakeEC2 struct {
+	ec2iface.EC2API
+}
+
+func (f *fakeEC2) DescribeInstances(in *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	r := &ec2.Reservation{}
+	for _, id := range in.InstanceIds {
+		r.Instances = append(r.Instances, &ec2.Instance{
+			InstanceId:      id,
+			PublicDnsName:   aws.String(*id + ".compute.amazonaws.com"),
+			PublicIpAddress: aws.String("10.0.0." + (*id)[2:]),
+		})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{r}}, nil
+}
+
+func TestStartTask(t *testing.T) {
+	svc := newFakeECS(0)
+	overrides := &ecs.TaskOverride{}
+	resp, err := StartTask(svc, aws.String("web:1"), aws.StringSlice([]string{"ci-1", "ci-2"}), aws.String("default"), aws.String("deploy"), overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Tasks) != 2 {
+		t.Fatalf("StartTask started %d tasks, want 2", len(resp.Tasks))
+	}
+	in := svc.started[0]
+	if *in.TaskDefinition != "web:1" || *in.Cluster != "default" || *in.StartedBy != "deploy" || in.Overrides != overrides {
+		t.Errorf("StartTask sent %v", in)
+	}
+}
+
+func TestStartWait(t *testing.T) {
+	svc := newFakeECS(2)
+	resp, err := StartWait(svc, aws.Int(5), aws.Int64(0), aws.String("web:1"), aws.StringSlice([]string{"ci-1", "ci-2"}), aws.String("production"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range resp.Tasks {
+		if *v.LastStatus != "RUNNING" {
+			t.Errorf("task %s is %s, want RUNNING", *v.TaskArn, *v.LastStatus)
+		}
+	}
+	for arn, polls := range svc.polls {
+		if polls != 3 {
+			t.Errorf("task %s was polled %d times, want 3", arn, polls)
+		}
+	}
+	for _, in := range svc.described {
+		if aws.StringValue(in.Cluster) != "production" {
+			t.Errorf("tasks described in cluster %q, want production", aws.StringValue(in.Cluster))
+		}
+	}
+}
+
+func TestStartWaitMaxTries(t *testing.T) {
+	svc := newFakeECS(-1)
+	_, err := StartWait(svc, aws.Int(3), aws.Int64(0), aws.String("web:1"), aws.StringSlice([]string{"ci-1"}), aws.String("default"), nil, nil)
+	if err == nil || err.Error() != "Max tries (3) reached" {
+		t.Errorf("StartWait error = %v, want max tries", err)
+	}
+}
+
+func TestStartWaitFailure(t *testing.T) {
+	svc := newFakeECS(0)
+	_, err := StartWait(svc, aws.Int(3), aws.Int64(0), aws.String("web:1"), aws.StringSlice([]string{"ci-9"}), aws.String("default"), nil, nil)
+	if err == nil || err.Error() != "failure reason: MISSING (ci-9)" {
+		t.Errorf("StartWait error = %v, want the start failure", err)
+	}
+}
+
+func TestCliStartWait(t *testing.T) {
+	c := &cli.Clients{ECS: newFakeECS(1), EC2: &fakeEC2{}}
+	ret, err := commands["start-wait"].Cmd(c, []string{"-timeout", "0", "-task-definition", "web:1", "-container-instances", "ci-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, ok := ret.Value.(*StartWaitOutput)
+	if !ok {
+		t.Fatalf("start-wait value is %T, want *StartWaitOutput", ret.Value)
+	}
+	if len(out.Tasks) != 1 || len(out.Instances) != 1 {
+		t.Fatalf("start-wait returned %d tasks and %d instances, want 1 and 1", len(out.Tasks), len(out.Instances))
+	}
+	if want := []string{*out.Tasks[0].TaskArn, "i-1.compute.amazonaws.com"}; !reflect.DeepEqual(ret.Lines, want) {
+		t.Errorf("start-wait lines = %v, want %v", ret.Lines, want)
+	}
+}
+
+func TestStopTask(t *testing.T) {
+	svc := newFakeECS(0)
+	start, err := StartTask(svc, aws.String("web:1"), aws.StringSlice([]string{"ci-1"}), aws.String("default"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := StopTask(svc, start.Tasks[0].TaskArn, aws.String("default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *resp.Task.DesiredStatus != "STOPPED" {
+		t.Errorf("stopped task desired status = %s", *resp.Task.DesiredStatus)
+	}
+	if _, err := StopTask(svc, aws.String("missing"), aws.String("default")); err == nil {
+		t.Error("StopTask of a missing task succeeded")
+	}
+}
+
+func TestRegisterTask(t *testing.T) {
+	svc := newFakeECS(0)
+	ports := []*ecs.PortMapping{{ContainerPort: aws.Int64(9000), HostPort: aws.Int64(80), Protocol: aws.String("tcp")}}
+	app := containerDef(aws.String("web"), ports, aws.String("nginx"), aws.Int64(128), aws.Int64(256), true, aws.StringSlice([]string{"db:db"}))
+	app.MountPoints = []*ecs.MountPoint{{SourceVolume: aws.String("assets"), ContainerPath: aws.String("/srv/assets")}}
+	volumes := []*ecs.Volume{
+		{Name: aws.String("assets"), Host: &ecs.HostVolumeProperties{SourcePath: aws.String("/srv/assets")}},
+		{Name: aws.String("unused")},
+	}
+	params := Definition(aws.String("web"), []*ecs.ContainerDefinition{app}, volumes)
+	resp, err := RegisterTask(svc, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *resp.TaskDefinition.Revision != 1 {
+		t.Errorf("revision = %d, want 1", *resp.TaskDefinition.Revision)
+	}
+	if len(params.ContainerDefinitions) != 1 {
+		t.Fatalf("Definition has %d containers, want 1", len(params.ContainerDefinitions))
+	}
+	if len(params.Volumes) != 1 || *params.Volumes[0].Name != "assets" {
+		t.Errorf("Definition volumes = %v, want only the mounted assets", params.Volumes)
+	}
+	if *app.Name != "web-app" || *app.Image != "nginx" || *app.Cpu != 128 || *app.Memory != 256 {
+		t.Errorf("unexpected app container %v", app)
+	}
+	if len(app.PortMappings) != 1 || *app.PortMappings[0].HostPort != 80 || *app.PortMappings[0].ContainerPort != 9000 {
+		t.Errorf("unexpected port mappings %v", app.PortMappings)
+	}
+}
+
+func TestCliRegisterTaskWithConsul(t *testing.T) {
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}, Region: "eu-west-1"}
+	ret, err := commands["register"].Cmd(c, []string{"-family", "web", "-image", "nginx", "-with-consul", "-target-instance", "ci-1", "-consul-server-instance", "ci-2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"arn:aws:ecs:us-east-1:123456789012:task-definition/web:1"}; !reflect.DeepEqual(ret.Lines, want) {
+		t.Errorf("register lines = %v, want %v", ret.Lines, want)
+	}
+	defs := svc.registered[0].ContainerDefinitions
+	if len(defs) != 3 {
+		t.Fatalf("registered %d containers, want 3", len(defs))
+	}
+	consul := defs[1]
+	if *consul.Hostname != "i-1" {
+		t.Errorf("consul hostname = %s, want i-1", *consul.Hostname)
+	}
+	if want := "-dc eu-west-1"; *consul.Command[2] != want {
+		t.Errorf("consul command = %s, want %s", *consul.Command[2], want)
+	}
+	if volumes := svc.registered[0].Volumes; len(volumes) != 3 {
+		t.Errorf("registered %d volumes, want the 3 consul volumes", len(volumes))
+	}
+}
+
+func TestCliRegisterTaskVolumes(t *testing.T) {
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}}
+	args := []string{"-family", "web", "-image", "example/web",
+		"-volume", "assets=/srv/assets", "-volume", "cache", "-volume", "unused=/tmp",
+		"-mount", "assets:/var/www/assets:ro", "-mount-path", "/var/cache/web", "-source-volume", "cache"}
+	if _, err := commands["register"].Cmd(c, args); err != nil {
+		t.Fatal(err)
+	}
+	params := svc.registered[0]
+	var volumes []string
+	for _, v := range params.Volumes {
+		volumes = append(volumes, *v.Name)
+	}
+	if want := []string{"assets", "cache"}; !reflect.DeepEqual(volumes, want) {
+		t.Errorf("registered volumes %v, want %v", volumes, want)
+	}
+	if params.Volumes[1].Host != nil {
+		t.Errorf("cache volume has a host path %v", params.Volumes[1].Host)
+	}
+	mounts := params.ContainerDefinitions[0].MountPoints
+	if len(mounts) != 2 || *mounts[0].ContainerPath != "/var/cache/web" || *mounts[0].ReadOnly || *mounts[1].SourceVolume != "assets" || !*mounts[1].ReadOnly {
+		t.Errorf("unexpected mount points %v", mounts)
+	}
+
+	if _, err := commands["register"].Cmd(c, []string{"-family", "web", "-image", "example/web"}); err != nil {
+		t.Fatal(err)
+	}
+	if params := svc.registered[1]; len(params.Volumes) != 0 || len(params.ContainerDefinitions[0].MountPoints) != 0 {
+		t.Errorf("registered volumes %v and mount points %v without any volume parameter", params.Volumes, params.ContainerDefinitions[0].MountPoints)
+	}
+	if _, err := commands["register"].Cmd(c, []string{"-family", "web", "-image", "example/web", "-mount-path", "/data"}); err == nil {
+		t.Error("register with a mount path without source volume succeeded")
+	}
+}
+
+func TestCliRegisterTaskPorts(t *testing.T) {
+	svc := newFakeECS(0)
+	c := &cli.Clients{ECS: svc, EC2: &fakeEC2{}}
+	if _, err := commands["register"].Cmd(c, []string{"-family", "web", "-image", "example/web", "-port", "9000", "-port", "0:9001", "-port", "8125:8125/udp"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []*ecs.PortMapping{
+		{ContainerPort: aws.Int64(9000), Protocol: aws.String("tcp")},
+		{ContainerPort: aws.Int64(9001), HostPort: aws.Int64(0), Protocol: aws.String("tcp")},
+		{ContainerPort: aws.Int64(8125), HostPort: aws.Int64(8125), Protocol: aws.String("udp")},
+	}
+	if got := svc.registered[0].ContainerDefinitions[0].PortMappings; !reflect.DeepEqual(got, want) {
+		t.Errorf("port mappings = %v, want %v", got, want)
+	}
+
+	if _, err := commands["register"].Cmd(c, []string{"-family", "web", "-image", "example/web", "-host-port", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.registered[1].ContainerDefinitions[0].PortMappings; len(got) != 1 || *got[0].HostPort != 0 || *got[0].ContainerPort != 9000 {
+		t.Errorf("port mappings with host port 0 = %v, want a dynamic host port", got)
+	}
+
+	if _, err := commands["register"].Cmd(c, []string{"-family", "worker", "-image", "example/worker", "-container-port", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.registered[2].ContainerDefinitions[0].PortMappings; len(got) != 0 {
+		t.Errorf("port mappings with container port 0 = %v, want none", got)
+	}
+}
+
+func TestBindingLines(t *testing.T) {
+	tasks := []*ecs.Task{{
+		TaskArn: aws.String("arn:task/1"),
+		Containers: []*ecs.Container{{
+			Name: aws.String("web-app"),
+			NetworkBindings: []*ecs.NetworkBinding{
+				{BindIP: aws.String("0.0.0.0"), HostPort: aws.Int64(32768), ContainerPort: aws.Int64(9000), Protocol: aws.String("tcp")},
+				{BindIP: aws.String("0.0.0.0"), HostPort: aws.Int64(8125), ContainerPort: aws.Int64(8125), Protocol: aws.String("udp")},
+			},
+		}},
+	}}
+	want := []string{
+		"arn:task/1 web-app 0.0.0.0:32768->9000/tcp",
+		"arn:task/1 web-app 0.0.0.0:8125->8125/udp",
+	}
+	if got := bindingLines(tasks); !reflect.DeepEqual(got, want) {
+		t.Errorf("bindingLines = %v, want %v", got, want)
+	}
+}
+
+func TestCliStartBindings(t *testing.T) {
+	b := fake.New(fake.DefaultState(), "us-east-1")
+	c := b.Clients()
+	if _, err := commands["register"].Cmd(c, []string{"-family", "web", "-image", "example/web", "-port", "0:9000"}); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"-task-definition", "web:1", "-container-instances", "00000000-0000-0000-0000-0000000000a1"}
+
+	// The bindings of a PENDING task are not reported
+	ret, err := commands["start"].Cmd(c, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arn := *ret.Value.(*ecs.StartTaskOutput).Tasks[0].TaskArn
+	if !reflect.DeepEqual(ret.Lines, []string{arn}) {
+		t.Errorf("start lines = %q, want the task ARN", ret.Lines)
+	}
+
+	ret, err = commands["start-wait"].Cmd(c, append(args, "-timeout", "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := ret.Value.(*StartWaitOutput).Tasks[0]
+	if *task.LastStatus != "RUNNING" || len(ret.Lines) < 2 || ret.Lines[1] != bindingLines([]*ecs.Task{task})[0] {
+		t.Errorf("start-wait lines = %q, want the bindings of the running task", ret.Lines)
+	}
+}
@@ -0,0 +1,217 @@
+// Package vars Substitutes variables in the task definition and configuration files.
+// A file can refer to a variable as ${NAME} or ${NAME:-default}, and $$ is a literal $.
+// A file whose name ends in .tmpl is also a Go template, where a variable is {{.NAME}}.
+// Other files are not templates, so {{...}} is kept as is, e.g. in the docker log options.
+// The values come from the environment variables, overridden by the
+// vars file, overridden by the variables of the command line.
+package vars
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/ghodss/yaml"
+)
+
+// Vars The values of the variables by name
+type Vars map[string]string
+
+// Load Reads a JSON or YAML file mapping the variable names to their values
+func Load(path string) (Vars, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, errors.New("Invalid vars file " + path + ": " + err.Error())
+	}
+	v := Vars{}
+	for name, value := range values {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, errors.New("Invalid vars file " + path + ": the value of " + name + " is not a string")
+		case nil:
+			v[name] = ""
+		default:
+			v[name] = fmt.Sprint(value)
+		}
+	}
+	return v, nil
+}
+
+// Set Sets the variables given in the name=value form
+func (v Vars) Set(defs []string) error {
+	for _, def := range defs {
+		kv := strings.SplitN(def, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return errors.New("Invalid variable: " + def + ". The format is name=value")
+		}
+		v[kv[0]] = kv[1]
+	}
+	return nil
+}
+
+// Lookup Returns the value of a variable, falling back to the environment variables
+func (v Vars) Lookup(name string) (string, bool) {
+	if value, ok := v[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// values Returns all the variables, including the environment variables
+func (v Vars) values() map[string]string {
+	ret := map[string]string{}
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 {
+			ret[kv[:i]] = kv[i+1:]
+		}
+	}
+	for name, value := range v {
+		ret[name] = value
+	}
+	return ret
+}
+
+var reference = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// TemplateExt The extension of the files rendered as Go templates
+const TemplateExt = ".tmpl"
+
+// Expand Substitutes the variables in the content of the named file.
+// A template file is rendered before the substitution, and the values it renders are not substituted again.
+// The error lists every variable without a value.
+func (v Vars) Expand(name string, data []byte) ([]byte, error) {
+	missing := map[string]bool{}
+	if strings.HasSuffix(name, TemplateExt) {
+		var err error
+		if data, err = v.render(name, data, missing); err != nil {
+			return nil, err
+		}
+	}
+	data = []byte(v.Substitute(string(data), missing))
+	if len(missing) > 0 {
+		return nil, Unresolved(name, missing)
+	}
+	return data, nil
+}
+
+// Render Renders the content of the named file if it is a template, and returns the content of other files unchanged.
+// The $ of the rendered values are escaped, so that a later substitution outputs them as is.
+func (v Vars) Render(name string, data []byte) ([]byte, error) {
+	if !strings.HasSuffix(name, TemplateExt) {
+		return data, nil
+	}
+	missing := map[string]bool{}
+	data, err := v.render(name, data, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, Unresolved(name, missing)
+	}
+	return data, nil
+}
+
+// Substitute Substitutes the variables in a value. The variables without a value are added to missing.
+func (v Vars) Substitute(s string, missing map[string]bool) string {
+	return reference.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$$" {
+			return "$"
+		}
+		m := reference.FindStringSubmatch(ref)
+		if value, ok := v.Lookup(m[1]); ok {
+			return value
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		missing[m[1]] = true
+		return ref
+	})
+}
+
+// Unresolved Returns the error listing the variables without a value in name
+func Unresolved(name string, missing map[string]bool) error {
+	var names []string
+	for k := range missing {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return errors.New("Unresolved variables in " + name + ": " + strings.Join(names, ", "))
+}
+
+// render Executes a Go template with the variables. The variables the template refers to without a value
+// are added to missing, and the template is then returned unchanged.
+func (v Vars) render(name string, data []byte, missing map[string]bool) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	values := v.values()
+	for _, field := range fields(tmpl.Tree.Root) {
+		if _, ok := values[field]; !ok {
+			missing[field] = true
+		}
+	}
+	if len(missing) > 0 {
+		return data, nil
+	}
+	for k, value := range values {
+		// The substitution turns $$ back into $
+		values[k] = strings.Replace(value, "$", "$$", -1)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// fields Returns the variable names referenced by the template actions
+func fields(node parse.Node) []string {
+	var ret []string
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		ret = append(ret, n.Ident[0])
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			ret = append(ret, n.Ident[1])
+		}
+	case *parse.ListNode:
+		if n != nil {
+			for _, c := range n.Nodes {
+				ret = append(ret, fields(c)...)
+			}
+		}
+	case *parse.ActionNode:
+		ret = fields(n.Pipe)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, c := range n.Cmds {
+				ret = append(ret, fields(c)...)
+			}
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			ret = append(ret, fields(a)...)
+		}
+	case *parse.IfNode:
+		ret = append(fields(n.Pipe), append(fields(n.List), fields(n.ElseList)...)...)
+	case *parse.RangeNode:
+		// The dot is not the variables in the body of range and with
+		ret = append(fields(n.Pipe), fields(n.ElseList)...)
+	case *parse.WithNode:
+		ret = append(fields(n.Pipe), fields(n.ElseList)...)
+	}
+	return ret
+}
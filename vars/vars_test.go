@@ -0,0 +1,92 @@
+package vars
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	os.Setenv("ECS_TEST_TAG", "from-env")
+	os.Setenv("ECS_TEST_MODE", "from-env")
+	defer os.Unsetenv("ECS_TEST_TAG")
+	defer os.Unsetenv("ECS_TEST_MODE")
+	v := Vars{"ECS_TEST_TAG": "abc123", "ECS_TEST_CMD": "{{.ECS_TEST_TAG}}"}
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"image: example/web:${ECS_TEST_TAG}", "image: example/web:abc123"},
+		{"mode: ${ECS_TEST_MODE}", "mode: from-env"},
+		{"cpu: ${ECS_TEST_CPU:-256}", "cpu: 256"},
+		{"tag: ${ECS_TEST_TAG:-latest}", "tag: abc123"},
+		{"cost: $$5 ${NOT A VAR}", "cost: $5 ${NOT A VAR}"},
+		{"tag: \"{{.ImageName}}/{{.Name}}\"", "tag: \"{{.ImageName}}/{{.Name}}\""},
+		{"command: ${ECS_TEST_CMD}", "command: {{.ECS_TEST_TAG}}"},
+	} {
+		got, err := v.Expand("test.yaml", []byte(tc.in))
+		if err != nil {
+			t.Errorf("Expand(%q) error: %s", tc.in, err)
+			continue
+		}
+		if string(got) != tc.want {
+			t.Errorf("Expand(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	v := Vars{"ECS_TEST_TAG": "abc123", "ECS_TEST_MODE": "production", "ECS_TEST_CMD": "echo ${HOME}"}
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"image: example/web:{{.ECS_TEST_TAG}}", "image: example/web:abc123"},
+		{"{{if eq .ECS_TEST_MODE \"production\"}}memory: 512{{else}}memory: 128{{end}}", "memory: 512"},
+		{"tag: \"{{`{{.Name}}`}}\" image: ${ECS_TEST_TAG}", "tag: \"{{.Name}}\" image: abc123"},
+		{"command: {{.ECS_TEST_CMD}}", "command: echo ${HOME}"},
+	} {
+		got, err := v.Expand("test.yaml.tmpl", []byte(tc.in))
+		if err != nil {
+			t.Errorf("Expand(%q) error: %s", tc.in, err)
+			continue
+		}
+		if string(got) != tc.want {
+			t.Errorf("Expand(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestExpandUnresolved(t *testing.T) {
+	v := Vars{"ECS_TEST_TAG": "abc123"}
+	_, err := v.Expand("taskdef.yaml.tmpl", []byte("image: ${ECS_TEST_IMAGE}:${ECS_TEST_TAG}\nhost: {{.ECS_TEST_HOST}}\nport: {{$.ECS_TEST_PORT}}\nuser: ${ECS_TEST_IMAGE}\n"))
+	if want := "Unresolved variables in taskdef.yaml.tmpl: ECS_TEST_HOST, ECS_TEST_IMAGE, ECS_TEST_PORT"; err == nil || err.Error() != want {
+		t.Errorf("Expand error = %v, want %s", err, want)
+	}
+}
+
+func TestLoadAndSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "vars.yaml")
+	if err := ioutil.WriteFile(path, []byte("tag: abc123\nreplicas: 3\ndebug: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Set([]string{"tag=def456", "url=http://example.com/?a=b"}); err != nil {
+		t.Fatal(err)
+	}
+	want := Vars{"tag": "def456", "replicas": "3", "debug": "false", "url": "http://example.com/?a=b"}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("vars = %v, want %v", v, want)
+	}
+	if err := v.Set([]string{"tag"}); err == nil {
+		t.Error("Set of a variable without value succeeded")
+	}
+}